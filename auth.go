@@ -30,6 +30,17 @@ type AuthStrategyWithContext interface {
 	TokenWithContext(ctx context.Context, client *Client) (string, error)
 }
 
+// TokenInvalidator is an optional extension interface for AuthStrategy implementations
+// that cache tokens.
+//
+// When a request is rejected with 401 Unauthorized, the client calls InvalidateToken
+// with the rejected token, acquires a new one and replays the request once.
+// Implementations should only drop the cached token if it still equals token,
+// so concurrent failures trigger a single refresh.
+type TokenInvalidator interface {
+	InvalidateToken(token string)
+}
+
 type NilAuth struct{}
 
 func (a *NilAuth) Token(client *Client) (string, error) { return "", nil }
@@ -63,8 +74,7 @@ func (a *TokenAuth) Clear() {
 type PasswordAuth struct {
 	client     *Client
 	collection string
-	identity   string
-	password   string
+	source     CredentialSource
 	auth       atomic.Pointer[authToken]
 
 	refreshSingle singleflight.Group
@@ -78,12 +88,19 @@ type authToken struct {
 	tokenExp time.Time
 }
 
+// NewPasswordAuth creates a PasswordAuth strategy that keeps the given
+// identity and password in memory for the life of the strategy.
 func NewPasswordAuth(client *Client, collection, identity, password string) *PasswordAuth {
+	return NewPasswordAuthWithSource(client, collection, StaticCredentials(identity, password))
+}
+
+// NewPasswordAuthWithSource creates a PasswordAuth strategy that reads the
+// identity and password from source only when a token has to be acquired.
+func NewPasswordAuthWithSource(client *Client, collection string, source CredentialSource) *PasswordAuth {
 	return &PasswordAuth{
 		client:     client,
 		collection: collection,
-		identity:   identity,
-		password:   password,
+		source:     source,
 	}
 }
 
@@ -95,7 +112,7 @@ func (a *PasswordAuth) TokenWithContext(ctx context.Context, client *Client) (st
 	currentAuth := a.auth.Load()
 
 	// Return immediately if token is valid (no lock)
-	if currentAuth != nil && time.Now().Add(tokenExpiryLeeway).Before(currentAuth.tokenExp) {
+	if currentAuth.valid() {
 		return currentAuth.token, nil
 	}
 
@@ -117,47 +134,108 @@ func (a *PasswordAuth) TokenWithContext(ctx context.Context, client *Client) (st
 }
 
 func (a *PasswordAuth) refreshToken(ctx context.Context, client *Client) error {
+	if a.source == nil {
+		return fmt.Errorf("pocketbase: password auth requires a CredentialSource")
+	}
+	identity, password, err := a.source.Credentials(ctx)
+	if err != nil {
+		return fmt.Errorf("pocketbase: load credentials: %w", err)
+	}
+
 	path := fmt.Sprintf("/api/collections/%s/auth-with-password", url.PathEscape(a.collection))
-	body := map[string]string{"identity": a.identity, "password": a.password}
+	body := map[string]string{"identity": identity, "password": password}
 
 	var authResponse AuthResponse
 	if err := client.send(ctx, http.MethodPost, path, body, &authResponse); err != nil {
 		return err
 	}
 
-	// --- ✨ Modified part: JWT parsing logic ---
-	var expiry time.Time
-	// Parse token using MapClaims that includes registered claims (RegisteredClaims).
-	// Here we don't verify signature, only extract expiration time information.
-	token, _, err := new(jwt.Parser).ParseUnverified(authResponse.Token, jwt.MapClaims{})
-	if err == nil {
-		// Get the 'exp' claim.
-		exp, err := token.Claims.GetExpirationTime()
-		if err == nil && exp != nil {
-			expiry = exp.Time
-		}
-	}
+	a.auth.Store(newAuthToken(&authResponse))
+
+	return nil
+}
 
-	// If parsing fails or there's no expiration time, set a short expiration time for safety.
+// newAuthToken converts an auth response into cached token state.
+// The expiry is read from the JWT 'exp' claim without verifying the signature.
+func newAuthToken(res *AuthResponse) *authToken {
+	expiry := tokenExpiry(res.Token)
+
+	// If parsing fails or there's no expiration time, set a short expiration time
+	// so the token is refreshed on a later request.
 	if expiry.IsZero() {
-		// Example: Set to expire after 1 minute to trigger refresh on next request
 		expiry = time.Now().Add(1 * time.Minute)
 	}
-	// --- ✨ End of modification ---
 
 	newAuth := &authToken{
-		token:    authResponse.Token,
-		tokenExp: expiry, // Set to parsed expiration time
+		token:    res.Token,
+		tokenExp: expiry,
 	}
-	if authResponse.Admin != nil {
-		newAuth.model = authResponse.Admin
+	if res.Admin != nil {
+		newAuth.model = res.Admin
 	} else {
-		newAuth.model = authResponse.Record
+		newAuth.model = res.Record
+	}
+	return newAuth
+}
+
+// tokenIdentity returns the auth record a token belongs to, or "" if it
+// isn't an auth token. The signature is not verified.
+func tokenIdentity(token string) string {
+	claims := parseTokenClaims(token)
+	id, _ := claims["id"].(string)
+	collectionID, _ := claims["collectionId"].(string)
+	if id == "" {
+		return ""
 	}
+	return collectionID + "/" + id
+}
 
-	a.auth.Store(newAuth)
+// tokenExpiry returns the expiry of a token, or the zero time.
+func tokenExpiry(token string) time.Time {
+	exp, err := parseTokenClaims(token).GetExpirationTime()
+	if err != nil || exp == nil {
+		return time.Time{}
+	}
+	return exp.Time
+}
 
-	return nil
+func parseTokenClaims(token string) jwt.MapClaims {
+	claims := jwt.MapClaims{}
+	if token == "" {
+		return claims
+	}
+	if _, _, err := new(jwt.Parser).ParseUnverified(token, claims); err != nil {
+		return jwt.MapClaims{}
+	}
+	return claims
+}
+
+// response rebuilds the AuthResponse for the cached token state.
+func (t *authToken) response(token string) *AuthResponse {
+	res := &AuthResponse{Token: token}
+	if admin, ok := t.model.(*Admin); ok {
+		res.Admin = admin
+	}
+	if record, ok := t.model.(*Record); ok {
+		res.Record = record
+	}
+	return res
+}
+
+// valid reports whether the token can still be used, taking tokenExpiryLeeway into account.
+func (t *authToken) valid() bool {
+	return t != nil && time.Now().Add(tokenExpiryLeeway).Before(t.tokenExp)
+}
+
+// InvalidateToken drops the cached token if it equals token.
+func (a *PasswordAuth) InvalidateToken(token string) {
+	invalidateAuthToken(&a.auth, token)
+}
+
+func invalidateAuthToken(auth *atomic.Pointer[authToken], token string) {
+	if current := auth.Load(); current != nil && current.token == token {
+		auth.CompareAndSwap(current, nil)
+	}
 }
 
 func (a *PasswordAuth) Clear() {
@@ -0,0 +1,66 @@
+package pocketbase
+
+import "context"
+
+type (
+	authOverrideKey      struct{}
+	authRetryDisabledKey struct{}
+)
+
+// ContextWithAuth returns a context that makes requests use strategy instead of
+// the client's AuthStore. This allows a single Client to act on behalf of
+// different users, e.g. when proxying end-user requests to PocketBase.
+//
+// The override also applies to RealtimeService.Subscribe when passed as the
+// subscription context. A nil strategy makes requests anonymous.
+func ContextWithAuth(ctx context.Context, strategy AuthStrategy) context.Context {
+	if strategy == nil {
+		strategy = &NilAuth{}
+	}
+	return context.WithValue(ctx, authOverrideKey{}, strategy)
+}
+
+// ContextWithToken is a shortcut for ContextWithAuth with a static token.
+// Realtime subscriptions made with the same token share a connection, even
+// across separate ContextWithToken calls.
+func ContextWithToken(ctx context.Context, token string) context.Context {
+	return ContextWithAuth(ctx, contextToken(token))
+}
+
+// contextToken is the strategy of ContextWithToken. Unlike *TokenAuth it is
+// compared by value, so realtime connections are keyed by the token itself.
+type contextToken string
+
+func (t contextToken) Token(client *Client) (string, error) { return string(t), nil }
+
+func (t contextToken) TokenWithContext(ctx context.Context, client *Client) (string, error) {
+	return string(t), nil
+}
+
+func (t contextToken) Clear() {}
+
+// ContextWithoutAuth returns a context that sends requests without an
+// Authorization header, even if the client itself is authenticated.
+func ContextWithoutAuth(ctx context.Context) context.Context {
+	return ContextWithAuth(ctx, &NilAuth{})
+}
+
+func authFromContext(ctx context.Context) (AuthStrategy, bool) {
+	strategy, ok := ctx.Value(authOverrideKey{}).(AuthStrategy)
+	return strategy, ok
+}
+
+// ContextWithoutAuthRetry returns a context that disables the automatic
+// retry on 401 Unauthorized for requests made with it.
+//
+// By default, when a request fails with 401 and the auth strategy implements
+// TokenInvalidator, the client drops the cached token, acquires a new one and
+// replays the request once.
+func ContextWithoutAuthRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, authRetryDisabledKey{}, true)
+}
+
+func authRetryDisabled(ctx context.Context) bool {
+	disabled, _ := ctx.Value(authRetryDisabledKey{}).(bool)
+	return disabled
+}
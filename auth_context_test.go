@@ -0,0 +1,129 @@
+package pocketbase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestContextWithAuth(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		got = append(got, r.Header.Get("Authorization"))
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"id":"rec1"}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	c.WithToken("superuser-token")
+
+	ctx := context.Background()
+	if _, err := c.Records.GetOne(ctx, "posts", "rec1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Records.GetOne(ContextWithToken(ctx, "user-token"), "posts", "rec1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Records.GetOne(ContextWithAuth(ctx, NewTokenAuth("strategy-token")), "posts", "rec1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Records.GetOne(ContextWithoutAuth(ctx), "posts", "rec1", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"superuser-token", "user-token", "strategy-token", ""}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != len(want) {
+		t.Fatalf("expected %d requests, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("request %d: expected Authorization %q, got %q", i, want[i], got[i])
+		}
+	}
+}
+
+func TestContextWithAuthRealtime(t *testing.T) {
+	var mu sync.Mutex
+	var auths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		auths = append(auths, r.Method+" "+r.Header.Get("Authorization"))
+		mu.Unlock()
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "event: PB_CONNECT\ndata: {\"clientId\":\"test-client-id\"}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	c.WithToken("superuser-token")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	unsub, err := c.Realtime.Subscribe(ContextWithToken(ctx, "user-token"), []string{"posts"}, func(*RealtimeEvent, error) {})
+	if err != nil {
+		t.Fatalf("subscribe err: %v", err)
+	}
+	unsub()
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, a := range auths {
+		if a != "GET user-token" && a != "POST user-token" {
+			t.Errorf("unexpected request authorization: %q", a)
+		}
+	}
+	if len(auths) != 2 {
+		t.Fatalf("expected GET and POST requests, got %v", auths)
+	}
+}
+
+func TestContextWithTokenSharesRealtimeConnection(t *testing.T) {
+	var connects int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		n := atomic.AddInt32(&connects, 1)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "event: PB_CONNECT\ndata: {\"clientId\":\"c%d\"}\n\n", n)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for _, topic := range []string{"posts", "comments"} {
+		unsub, err := c.Realtime.Subscribe(ContextWithToken(ctx, "user-token"), []string{topic}, func(*RealtimeEvent, error) {})
+		if err != nil {
+			t.Fatalf("subscribe err: %v", err)
+		}
+		defer unsub()
+	}
+
+	if got := atomic.LoadInt32(&connects); got != 1 {
+		t.Fatalf("expected one shared connection, got %d", got)
+	}
+	if st := c.Realtime.(*RealtimeService).Status(ContextWithToken(ctx, "user-token")); st.State != RealtimeConnected {
+		t.Fatalf("expected the shared connection's status, got %v", st.State)
+	}
+}
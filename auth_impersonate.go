@@ -0,0 +1,241 @@
+package pocketbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// TokenStore persists tokens so that several processes (or restarts of the
+// same process) can share one impersonation token.
+type TokenStore interface {
+	// LoadToken returns the stored token, or an empty string if there is none.
+	LoadToken(ctx context.Context) (string, error)
+	// SaveToken stores token, replacing any previous value.
+	SaveToken(ctx context.Context, token string) error
+}
+
+// MemoryTokenStore is a TokenStore that keeps the token in memory.
+type MemoryTokenStore struct {
+	mu    sync.Mutex
+	token string
+}
+
+// LoadToken returns the stored token.
+func (s *MemoryTokenStore) LoadToken(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token, nil
+}
+
+// SaveToken stores token.
+func (s *MemoryTokenStore) SaveToken(ctx context.Context, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+	return nil
+}
+
+// FileTokenStore is a TokenStore backed by a file, for example on a volume
+// shared by several workers. The file is written with 0600 permissions.
+type FileTokenStore struct {
+	Path string
+}
+
+// LoadToken reads the token file. A missing file is not an error.
+func (s *FileTokenStore) LoadToken(ctx context.Context) (string, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("pocketbase: read token file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// SaveToken atomically replaces the token file. Each call writes its own
+// temporary file in the same directory, so concurrent saves don't interfere.
+func (s *FileTokenStore) SaveToken(ctx context.Context, token string) error {
+	f, err := os.CreateTemp(filepath.Dir(s.Path), "."+filepath.Base(s.Path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("pocketbase: write token file: %w", err)
+	}
+	tmp := f.Name()
+	_, err = f.WriteString(token)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, s.Path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("pocketbase: write token file: %w", err)
+	}
+	return nil
+}
+
+// ImpersonationAuthOptions configures ImpersonationAuth.
+type ImpersonationAuthOptions struct {
+	// Bootstrap provides the superuser credentials used to mint tokens.
+	// It is consulted only when a new impersonation token is needed.
+	Bootstrap CredentialSource
+	// BootstrapCollection is the collection Bootstrap authenticates against.
+	// Defaults to "_superusers".
+	BootstrapCollection string
+
+	// Collection and RecordID identify the service account to impersonate.
+	Collection string
+	RecordID   string
+
+	// Duration is the requested token lifetime. Zero uses the collection's
+	// default auth token duration.
+	Duration time.Duration
+	// RotateBefore is how long before expiry a new token is minted.
+	// Defaults to a fifth of Duration, but at least 30 seconds.
+	RotateBefore time.Duration
+
+	// Store optionally persists minted tokens. A valid stored token is used
+	// before minting a new one, so most processes never need Bootstrap.
+	Store TokenStore
+}
+
+// ImpersonationAuth is an AuthStrategy for backend jobs that authenticates
+// with long-lived, non-refreshable impersonation tokens.
+//
+// The bootstrap superuser credentials are used only to call the impersonate
+// endpoint; afterwards requests carry the impersonation token alone. Tokens
+// are rotated before they expire and can be shared through a TokenStore.
+type ImpersonationAuth struct {
+	client *Client
+	opts   ImpersonationAuthOptions
+	auth   atomic.Pointer[authToken]
+
+	// rejected holds the last token invalidated after a 401, so it isn't
+	// picked up again from the store.
+	rejected atomic.Value
+
+	refreshSingle singleflight.Group
+}
+
+var (
+	_ AuthStrategyWithContext = (*ImpersonationAuth)(nil)
+	_ TokenInvalidator        = (*ImpersonationAuth)(nil)
+)
+
+// NewImpersonationAuth creates an ImpersonationAuth strategy.
+func NewImpersonationAuth(client *Client, opts ImpersonationAuthOptions) *ImpersonationAuth {
+	if opts.BootstrapCollection == "" {
+		opts.BootstrapCollection = "_superusers"
+	}
+	if opts.RotateBefore <= 0 {
+		opts.RotateBefore = opts.Duration / 5
+	}
+	if opts.RotateBefore < tokenExpiryLeeway {
+		opts.RotateBefore = tokenExpiryLeeway
+	}
+	return &ImpersonationAuth{client: client, opts: opts}
+}
+
+func (a *ImpersonationAuth) Token(client *Client) (string, error) {
+	return a.TokenWithContext(context.Background(), client)
+}
+
+func (a *ImpersonationAuth) TokenWithContext(ctx context.Context, client *Client) (string, error) {
+	if currentAuth := a.auth.Load(); a.fresh(currentAuth) {
+		return currentAuth.token, nil
+	}
+
+	_, err, _ := a.refreshSingle.Do("refresh", func() (any, error) {
+		return nil, a.refreshToken(ctx, client)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	refreshedAuth := a.auth.Load()
+	if refreshedAuth == nil {
+		return "", fmt.Errorf("authentication failed: token not available after refresh")
+	}
+
+	return refreshedAuth.token, nil
+}
+
+// fresh reports whether t can be used without rotating it.
+func (a *ImpersonationAuth) fresh(t *authToken) bool {
+	return t != nil && time.Now().Add(a.opts.RotateBefore).Before(t.tokenExp)
+}
+
+func (a *ImpersonationAuth) refreshToken(ctx context.Context, client *Client) error {
+	if a.opts.Store != nil {
+		stored, err := a.opts.Store.LoadToken(ctx)
+		if err != nil {
+			return fmt.Errorf("pocketbase: load impersonation token: %w", err)
+		}
+		if rejected, _ := a.rejected.Load().(string); stored != "" && stored != rejected {
+			if t := newAuthToken(&AuthResponse{Token: stored}); a.fresh(t) {
+				a.auth.Store(t)
+				return nil
+			}
+		}
+	}
+
+	token, err := a.mint(ctx, client)
+	if err != nil {
+		return err
+	}
+	a.auth.Store(newAuthToken(&AuthResponse{Token: token}))
+
+	if a.opts.Store != nil {
+		if err := a.opts.Store.SaveToken(ctx, token); err != nil {
+			return fmt.Errorf("pocketbase: save impersonation token: %w", err)
+		}
+	}
+	return nil
+}
+
+// mint authenticates with the bootstrap credentials for this call only and
+// requests a new impersonation token.
+func (a *ImpersonationAuth) mint(ctx context.Context, client *Client) (string, error) {
+	if a.opts.Bootstrap == nil {
+		return "", fmt.Errorf("pocketbase: impersonation auth requires bootstrap credentials")
+	}
+	bootstrap := NewPasswordAuthWithSource(client, a.opts.BootstrapCollection, a.opts.Bootstrap)
+	defer bootstrap.Clear()
+
+	users := &UserService{Client: client}
+	impersonated, err := users.Impersonate(ContextWithAuth(ctx, bootstrap), a.opts.Collection, a.opts.RecordID, int(a.opts.Duration/time.Second))
+	if err != nil {
+		return "", fmt.Errorf("pocketbase: impersonate: %w", err)
+	}
+	token, err := impersonated.AuthStore.Token(impersonated)
+	if err != nil {
+		return "", err
+	}
+	if token == "" {
+		return "", fmt.Errorf("pocketbase: impersonate: response missing token")
+	}
+	return token, nil
+}
+
+// InvalidateToken drops the cached token if it equals token.
+func (a *ImpersonationAuth) InvalidateToken(token string) {
+	a.rejected.Store(token)
+	invalidateAuthToken(&a.auth, token)
+}
+
+func (a *ImpersonationAuth) Clear() {
+	a.auth.Store(nil)
+}
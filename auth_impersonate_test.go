@@ -0,0 +1,153 @@
+package pocketbase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+type impersonationServer struct {
+	*httptest.Server
+	logins  int32
+	mints   int32
+	expires time.Duration
+}
+
+func newImpersonationServer(t *testing.T, expires time.Duration) *impersonationServer {
+	t.Helper()
+	s := &impersonationServer{expires: expires}
+	superToken := newTestJWT(t, time.Now().Add(time.Hour))
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/collections/_superusers/auth-with-password":
+			atomic.AddInt32(&s.logins, 1)
+			fmt.Fprintf(w, `{"token":"%s","record":{"id":"su1"}}`, superToken)
+		case r.URL.Path == "/api/collections/services/impersonate/svc1":
+			if r.Header.Get("Authorization") != superToken {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			var body map[string]int
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["duration"] != 86400 {
+				t.Errorf("unexpected duration: %d", body["duration"])
+			}
+			n := atomic.AddInt32(&s.mints, 1)
+			tok := newTestJWT(t, time.Now().Add(s.expires+time.Duration(n)*time.Second))
+			fmt.Fprintf(w, `{"token":"%s","record":{"id":"svc1"}}`, tok)
+		case strings.HasPrefix(r.URL.Path, "/api/collections/posts/records"):
+			if r.Header.Get("Authorization") == superToken || r.Header.Get("Authorization") == "" {
+				t.Errorf("request must use the impersonation token")
+			}
+			_, _ = w.Write([]byte(`{"id":"rec1"}`))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	return s
+}
+
+func impersonationOptions(store TokenStore) ImpersonationAuthOptions {
+	return ImpersonationAuthOptions{
+		Bootstrap:  StaticCredentials("admin@example.com", "password"),
+		Collection: "services",
+		RecordID:   "svc1",
+		Duration:   24 * time.Hour,
+		Store:      store,
+	}
+}
+
+func TestImpersonationAuth(t *testing.T) {
+	srv := newImpersonationServer(t, 24*time.Hour)
+	defer srv.Close()
+
+	store := &MemoryTokenStore{}
+	c := NewClient(srv.URL)
+	c.WithAuthStrategy(NewImpersonationAuth(c, impersonationOptions(store)))
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Records.GetOne(context.Background(), "posts", "rec1", nil); err != nil {
+			t.Fatalf("GetOne failed: %v", err)
+		}
+	}
+	if atomic.LoadInt32(&srv.logins) != 1 || atomic.LoadInt32(&srv.mints) != 1 {
+		t.Fatalf("expected a single mint, got %d logins and %d mints", srv.logins, srv.mints)
+	}
+	if tok, _ := store.LoadToken(context.Background()); tok == "" {
+		t.Fatal("minted token should be saved to the store")
+	}
+
+	// A second process sharing the store must not need the bootstrap credentials.
+	other := NewClient(srv.URL)
+	opts := impersonationOptions(store)
+	opts.Bootstrap = nil
+	other.WithAuthStrategy(NewImpersonationAuth(other, opts))
+	if _, err := other.Records.GetOne(context.Background(), "posts", "rec1", nil); err != nil {
+		t.Fatalf("GetOne with stored token failed: %v", err)
+	}
+	if atomic.LoadInt32(&srv.logins) != 1 {
+		t.Fatalf("stored token should be reused, got %d logins", srv.logins)
+	}
+}
+
+func TestImpersonationAuthRotation(t *testing.T) {
+	// Tokens expiring within RotateBefore are replaced on the next request.
+	srv := newImpersonationServer(t, time.Hour)
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	auth := NewImpersonationAuth(c, impersonationOptions(&FileTokenStore{Path: filepath.Join(t.TempDir(), "token")}))
+	first, err := auth.Token(c)
+	if err != nil {
+		t.Fatalf("Token failed: %v", err)
+	}
+	second, err := auth.Token(c)
+	if err != nil {
+		t.Fatalf("Token failed: %v", err)
+	}
+	if first == second || atomic.LoadInt32(&srv.mints) != 2 {
+		t.Fatalf("expected token to be rotated, got %d mints", srv.mints)
+	}
+}
+
+func TestFileTokenStoreConcurrentSaves(t *testing.T) {
+	dir := t.TempDir()
+	store := &FileTokenStore{Path: filepath.Join(dir, "token")}
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- store.SaveToken(ctx, fmt.Sprintf("token-%d", i))
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("SaveToken failed: %v", err)
+		}
+	}
+
+	token, err := store.LoadToken(ctx)
+	if err != nil || !strings.HasPrefix(token, "token-") {
+		t.Fatalf("unexpected token %q: %v", token, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("expected only the token file to remain, got %d entries", len(entries))
+	}
+}
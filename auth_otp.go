@@ -0,0 +1,105 @@
+package pocketbase
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// OTPProvider returns the one-time password for the given OTP request id.
+// It is called every time OTPAuth needs a new token, for example to read the
+// code from a mailbox or a secrets channel.
+type OTPProvider func(ctx context.Context, otpID string) (string, error)
+
+// OTPAuth is a passwordless AuthStrategy based on one-time passwords.
+// When the cached token is missing or about to expire it requests an OTP,
+// obtains the code from the OTPProvider and completes auth-with-otp.
+type OTPAuth struct {
+	client     *Client
+	collection string
+	email      string
+	provider   OTPProvider
+	auth       atomic.Pointer[authToken]
+
+	refreshSingle singleflight.Group
+}
+
+var (
+	_ AuthStrategyWithContext = (*OTPAuth)(nil)
+	_ TokenInvalidator        = (*OTPAuth)(nil)
+)
+
+// NewOTPAuth creates an OTPAuth strategy for the given auth collection and email.
+func NewOTPAuth(client *Client, collection, email string, provider OTPProvider) *OTPAuth {
+	return &OTPAuth{
+		client:     client,
+		collection: collection,
+		email:      email,
+		provider:   provider,
+	}
+}
+
+func (a *OTPAuth) Token(client *Client) (string, error) {
+	return a.TokenWithContext(context.Background(), client)
+}
+
+func (a *OTPAuth) TokenWithContext(ctx context.Context, client *Client) (string, error) {
+	if currentAuth := a.auth.Load(); currentAuth.valid() {
+		return currentAuth.token, nil
+	}
+
+	_, err, _ := a.refreshSingle.Do("refresh", func() (any, error) {
+		return nil, a.refreshToken(ctx, client)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	refreshedAuth := a.auth.Load()
+	if refreshedAuth == nil {
+		return "", fmt.Errorf("authentication failed: token not available after refresh")
+	}
+
+	return refreshedAuth.token, nil
+}
+
+func (a *OTPAuth) refreshToken(ctx context.Context, client *Client) error {
+	if a.provider == nil {
+		return fmt.Errorf("pocketbase: otp auth requires an OTPProvider")
+	}
+
+	users := &UserService{Client: client}
+	otp, err := users.RequestOTP(ctx, a.collection, a.email)
+	if err != nil {
+		return fmt.Errorf("pocketbase: request otp: %w", err)
+	}
+	otpID := otp["otpId"]
+	if otpID == "" {
+		return fmt.Errorf("pocketbase: request otp: response missing otpId")
+	}
+
+	code, err := a.provider(ctx, otpID)
+	if err != nil {
+		return fmt.Errorf("pocketbase: otp provider: %w", err)
+	}
+
+	authResponse, err := users.AuthWithOTP(ctx, a.collection, otpID, code)
+	if err != nil {
+		return err
+	}
+
+	a.auth.Store(newAuthToken(authResponse))
+
+	return nil
+}
+
+// InvalidateToken drops the cached token if it equals token.
+func (a *OTPAuth) InvalidateToken(token string) {
+	invalidateAuthToken(&a.auth, token)
+}
+
+func (a *OTPAuth) Clear() {
+	a.auth.Store(nil)
+}
@@ -0,0 +1,174 @@
+package pocketbase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestJWT(t *testing.T, exp time.Time) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(exp),
+	})
+	s, err := token.SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("Failed to create test token: %v", err)
+	}
+	return s
+}
+
+// otpMailbox is a mailbox stub that stores the codes "sent" by the test server.
+type otpMailbox struct {
+	mu    sync.Mutex
+	codes map[string]string
+}
+
+func (m *otpMailbox) put(otpID, code string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.codes == nil {
+		m.codes = make(map[string]string)
+	}
+	m.codes[otpID] = code
+}
+
+func (m *otpMailbox) read(ctx context.Context, otpID string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	code, ok := m.codes[otpID]
+	if !ok {
+		return "", fmt.Errorf("no otp for %s", otpID)
+	}
+	return code, nil
+}
+
+func newOTPTestServer(t *testing.T, mailbox *otpMailbox, tokenString string, requests *int32) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/collections/users/request-otp":
+			n := atomic.AddInt32(requests, 1)
+			otpID := fmt.Sprintf("otp%d", n)
+			mailbox.put(otpID, "123456")
+			_ = json.NewEncoder(w).Encode(map[string]string{"otpId": otpID})
+		case "/api/collections/users/auth-with-otp":
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["password"] != "123456" || body["otpId"] == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"code":400,"message":"Failed to authenticate."}`))
+				return
+			}
+			fmt.Fprintf(w, `{"token":"%s","record":{"id":"user1"}}`, tokenString)
+		case "/api/collections/posts/records":
+			if r.Header.Get("Authorization") != tokenString {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"page":1,"perPage":30,"totalItems":0,"totalPages":0,"items":[]}`))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+}
+
+func TestOTPAuth_Token(t *testing.T) {
+	tokenString := newTestJWT(t, time.Now().Add(time.Hour))
+	mailbox := &otpMailbox{}
+	var otpRequests int32
+	srv := newOTPTestServer(t, mailbox, tokenString, &otpRequests)
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	res, err := c.WithOTP(context.Background(), "users", "test@example.com", mailbox.read)
+	if err != nil {
+		t.Fatalf("WithOTP failed: %v", err)
+	}
+	if res.Token != tokenString || res.Record == nil || res.Record.ID != "user1" {
+		t.Fatalf("unexpected auth response: %+v", res)
+	}
+
+	if _, err := c.Records.GetList(context.Background(), "posts", nil); err != nil {
+		t.Fatalf("GetList failed: %v", err)
+	}
+	if got := atomic.LoadInt32(&otpRequests); got != 1 {
+		t.Fatalf("expected cached token to be reused, got %d otp requests", got)
+	}
+}
+
+func TestOTPAuth_RefreshesExpiredToken(t *testing.T) {
+	// A token inside the expiry leeway must be refreshed on every call.
+	tokenString := newTestJWT(t, time.Now().Add(10*time.Second))
+	mailbox := &otpMailbox{}
+	var otpRequests int32
+	srv := newOTPTestServer(t, mailbox, tokenString, &otpRequests)
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	auth := NewOTPAuth(c, "users", "test@example.com", mailbox.read)
+	for i := 0; i < 2; i++ {
+		if _, err := auth.Token(c); err != nil {
+			t.Fatalf("Token failed: %v", err)
+		}
+	}
+	if got := atomic.LoadInt32(&otpRequests); got != 2 {
+		t.Fatalf("expected 2 otp requests, got %d", got)
+	}
+
+	auth.Clear()
+	if auth.auth.Load() != nil {
+		t.Fatal("Auth state should be nil after Clear is called")
+	}
+}
+
+func TestOTPAuth_ProviderError(t *testing.T) {
+	mailbox := &otpMailbox{}
+	var otpRequests int32
+	srv := newOTPTestServer(t, mailbox, "unused", &otpRequests)
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	auth := NewOTPAuth(c, "users", "test@example.com", func(ctx context.Context, otpID string) (string, error) {
+		return "", fmt.Errorf("mailbox unavailable")
+	})
+	c.WithAuthStrategy(auth)
+
+	if _, err := auth.Token(c); err == nil {
+		t.Fatal("expected provider error")
+	}
+	if auth.auth.Load() != nil {
+		t.Fatal("auth state should be nil after a failed refresh")
+	}
+}
+
+func TestOTPAuthRace(t *testing.T) {
+	tokenString := newTestJWT(t, time.Now().Add(time.Hour))
+	mailbox := &otpMailbox{}
+	var otpRequests int32
+	srv := newOTPTestServer(t, mailbox, tokenString, &otpRequests)
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	c.WithAuthStrategy(NewOTPAuth(c, "users", "test@example.com", mailbox.read))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Records.GetList(context.Background(), "posts", nil); err != nil {
+				t.Errorf("GetList failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -1,9 +1,14 @@
 package pocketbase
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -123,3 +128,88 @@ func TestPasswordAuth_Token_RefreshFailure(t *testing.T) {
 		t.Fatal("auth.auth should be nil after a failed refresh")
 	}
 }
+
+func newRevokingServer(t *testing.T, tokens []string, logins *int32) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/collections/users/auth-with-password" {
+			n := int(atomic.AddInt32(logins, 1))
+			if n > len(tokens) {
+				n = len(tokens)
+			}
+			fmt.Fprintf(w, `{"token":"%s","record":{"id":"user1"}}`, tokens[n-1])
+			return
+		}
+		// Only the most recent token is accepted; earlier ones are "revoked".
+		if r.Header.Get("Authorization") != tokens[len(tokens)-1] {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"code":401,"message":"The request requires valid record authorization token."}`))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"rec1","collectionName":"posts","echo":%q}`, string(body))
+	}))
+}
+
+func TestAuthRetryOnUnauthorized(t *testing.T) {
+	tokens := []string{newTestJWT(t, time.Now().Add(time.Hour)), newTestJWT(t, time.Now().Add(2*time.Hour))}
+	var logins int32
+	srv := newRevokingServer(t, tokens, &logins)
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	if _, err := c.WithPassword(context.Background(), "users", "testuser", "testpass"); err != nil {
+		t.Fatalf("WithPassword failed: %v", err)
+	}
+
+	rec, err := c.Records.Create(context.Background(), "posts", map[string]any{"title": "hello"})
+	if err != nil {
+		t.Fatalf("Create should succeed after refresh, got: %v", err)
+	}
+	if !strings.Contains(rec.GetString("echo"), "hello") {
+		t.Fatalf("request body was not replayed: %q", rec.GetString("echo"))
+	}
+	if got := atomic.LoadInt32(&logins); got != 2 {
+		t.Fatalf("expected exactly one forced refresh, got %d logins", got)
+	}
+}
+
+func TestAuthRetryDisabled(t *testing.T) {
+	tokens := []string{newTestJWT(t, time.Now().Add(time.Hour)), newTestJWT(t, time.Now().Add(2*time.Hour))}
+	var logins int32
+	srv := newRevokingServer(t, tokens, &logins)
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	if _, err := c.WithPassword(context.Background(), "users", "testuser", "testpass"); err != nil {
+		t.Fatalf("WithPassword failed: %v", err)
+	}
+
+	_, err := c.Records.GetOne(ContextWithoutAuthRetry(context.Background()), "posts", "rec1", nil)
+	if !errors.Is(err, StatusUnauthorized) {
+		t.Fatalf("expected 401 error, got: %v", err)
+	}
+	if got := atomic.LoadInt32(&logins); got != 1 {
+		t.Fatalf("expected no refresh, got %d logins", got)
+	}
+}
+
+func TestAuthRetryNotForTokenAuth(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"code":401,"message":"The request requires valid record authorization token."}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	c.WithToken("static-token")
+	if _, err := c.Records.GetOne(context.Background(), "posts", "rec1", nil); !errors.Is(err, StatusUnauthorized) {
+		t.Fatalf("expected 401 error, got: %v", err)
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Fatalf("TokenAuth requests must not be retried, got %d requests", got)
+	}
+}
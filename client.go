@@ -31,6 +31,8 @@ type Client struct {
 	Batch       BatchServiceAPI      // General batch service
 	Legacy      LegacyServiceAPI     // Legacy API service
 	Files       FileServiceAPI       // Service for file operations
+
+	realtimeOpts RealtimeOptions
 }
 
 type authInjector struct {
@@ -40,30 +42,106 @@ type authInjector struct {
 
 func (t *authInjector) RoundTrip(req *http.Request) (*http.Response, error) {
 	// Avoid injecting a (possibly stale) token into auth bootstrap endpoints.
-	// Ex: /auth-with-password, /auth-with-oauth2, /auth-with-otp.
-	if strings.Contains(req.URL.Path, "/auth-with-") {
+	// Ex: /auth-with-password, /auth-with-oauth2, /auth-with-otp, /request-otp.
+	if isAuthBootstrapPath(req.URL.Path) {
 		return t.next.RoundTrip(req)
 	}
-	t.client.mu.RLock()
-	authStore := t.client.AuthStore
-	t.client.mu.RUnlock()
-
-	if authStore != nil {
-		var tok string
-		var err error
-		if withCtx, ok := authStore.(AuthStrategyWithContext); ok {
-			tok, err = withCtx.TokenWithContext(req.Context(), t.client)
-		} else {
-			tok, err = authStore.Token(t.client)
-		}
+	authStore := t.client.authStrategy(req.Context())
+	if authStore == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	tok, err := t.token(req.Context(), authStore)
+	if err != nil {
+		return nil, err
+	}
+	if tok != "" {
+		req.Header.Set("Authorization", tok)
+	}
+
+	res, err := t.next.RoundTrip(req)
+	if err != nil || res.StatusCode != http.StatusUnauthorized || tok == "" {
+		return res, err
+	}
+	return t.retryUnauthorized(req, res, authStore, tok)
+}
+
+// retryUnauthorized invalidates the rejected token and replays the request once
+// with a freshly acquired token. The original response is returned when the
+// strategy can't invalidate tokens, the body can't be replayed or the caller
+// opted out with ContextWithoutAuthRetry.
+func (t *authInjector) retryUnauthorized(req *http.Request, res *http.Response, authStore AuthStrategy, tok string) (*http.Response, error) {
+	invalidator, ok := authStore.(TokenInvalidator)
+	if !ok || authRetryDisabled(req.Context()) {
+		return res, nil
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return res, nil
+	}
+
+	invalidator.InvalidateToken(tok)
+	newTok, err := t.token(req.Context(), authStore)
+	if err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	if newTok == "" || newTok == tok {
+		return res, nil
+	}
+
+	retryReq := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
 		if err != nil {
-			return nil, err
-		}
-		if tok != "" {
-			req.Header.Set("Authorization", tok)
+			return res, nil
 		}
+		retryReq.Body = body
+	}
+	retryReq.Header.Set("Authorization", newTok)
+
+	_, _ = io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	return t.next.RoundTrip(retryReq)
+}
+
+func (t *authInjector) token(ctx context.Context, authStore AuthStrategy) (string, error) {
+	if withCtx, ok := authStore.(AuthStrategyWithContext); ok {
+		return withCtx.TokenWithContext(ctx, t.client)
 	}
-	return t.next.RoundTrip(req)
+	return authStore.Token(t.client)
+}
+
+// authStrategy returns the strategy that authorizes requests made with ctx.
+func (c *Client) authStrategy(ctx context.Context) AuthStrategy {
+	if authStore, ok := authFromContext(ctx); ok {
+		return authStore
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.AuthStore
+}
+
+// currentToken returns the token requests made with ctx are sent with.
+func (c *Client) currentToken(ctx context.Context) (string, error) {
+	authStore := c.authStrategy(ctx)
+	if authStore == nil {
+		return "", nil
+	}
+	return (&authInjector{client: c}).token(ctx, authStore)
+}
+
+// authChanged lets the realtime service follow a change of the client's auth.
+func (c *Client) authChanged(cleared bool) {
+	if rs, ok := c.Realtime.(*RealtimeService); ok {
+		rs.authChanged(cleared)
+	}
+}
+
+// isAuthBootstrapPath reports whether path is used to obtain a token.
+// Strategies call these endpoints while refreshing, so they must not
+// trigger another token lookup.
+func isAuthBootstrapPath(path string) bool {
+	return strings.Contains(path, "/auth-with-") || strings.HasSuffix(path, "/request-otp")
 }
 
 // NewClient creates a new Client with the given baseURL.
@@ -84,7 +162,7 @@ func NewClient(baseURL string, opts ...ClientOption) *Client {
 	c.HTTPClient.Transport = &authInjector{client: c, next: transport}
 	c.Collections = &CollectionService{Client: c}
 	c.Records = &RecordService{Client: c}
-	c.Realtime = &RealtimeService{Client: c}
+	c.Realtime = &RealtimeService{Client: c, Options: c.realtimeOpts}
 	c.Admins = &AdminService{Client: c}
 	c.Users = &UserService{Client: c}
 	c.Logs = &LogService{Client: c}
@@ -96,14 +174,17 @@ func NewClient(baseURL string, opts ...ClientOption) *Client {
 }
 
 // ClearAuthStore removes the stored authentication information.
+// Realtime subscriptions made with the client's auth are closed with
+// ErrRealtimeAuthCleared.
 func (c *Client) ClearAuthStore() {
 	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	if c.AuthStore != nil {
 		c.AuthStore.Clear()
 		c.AuthStore = &NilAuth{}
 	}
+	c.mu.Unlock()
+
+	c.authChanged(true)
 }
 
 // newRequest performs common request initialization.
@@ -228,7 +309,13 @@ func (c *Client) do(ctx context.Context, method, path string, body io.Reader, co
 
 // WithPassword creates a PasswordAuth strategy and sets it to the client.
 func (c *Client) WithPassword(ctx context.Context, collection, identity, password string) (*AuthResponse, error) {
-	authStrategy := NewPasswordAuth(c, collection, identity, password)
+	return c.WithCredentialSource(ctx, collection, StaticCredentials(identity, password))
+}
+
+// WithCredentialSource creates a PasswordAuth strategy backed by source and sets it to the client.
+// The source is consulted only when a token has to be acquired or refreshed.
+func (c *Client) WithCredentialSource(ctx context.Context, collection string, source CredentialSource) (*AuthResponse, error) {
+	authStrategy := NewPasswordAuthWithSource(c, collection, source)
 	c.mu.Lock()
 	c.AuthStore = authStrategy
 	c.mu.Unlock()
@@ -245,15 +332,31 @@ func (c *Client) WithPassword(ctx context.Context, collection, identity, passwor
 		return nil, fmt.Errorf("authentication succeeded but no auth data is available")
 	}
 
-	res := &AuthResponse{Token: token}
-	if admin, ok := currentAuth.model.(*Admin); ok {
-		res.Admin = admin
+	c.authChanged(false)
+	return currentAuth.response(token), nil
+}
+
+// WithOTP creates an OTPAuth strategy and sets it to the client.
+// The provider is called whenever a new one-time password is needed.
+func (c *Client) WithOTP(ctx context.Context, collection, email string, provider OTPProvider) (*AuthResponse, error) {
+	authStrategy := NewOTPAuth(c, collection, email, provider)
+	c.mu.Lock()
+	c.AuthStore = authStrategy
+	c.mu.Unlock()
+
+	token, err := authStrategy.TokenWithContext(ctx, c)
+	if err != nil {
+		c.ClearAuthStore()
+		return nil, err
 	}
-	if record, ok := currentAuth.model.(*Record); ok {
-		res.Record = record
+
+	currentAuth := authStrategy.auth.Load()
+	if currentAuth == nil {
+		return nil, fmt.Errorf("authentication succeeded but no auth data is available")
 	}
 
-	return res, nil
+	c.authChanged(false)
+	return currentAuth.response(token), nil
 }
 
 // WithAdminPassword is a convenience method for WithPassword.
@@ -264,9 +367,10 @@ func (c *Client) WithAdminPassword(ctx context.Context, identity, password strin
 // WithToken sets a TokenAuth strategy to the client.
 func (c *Client) WithToken(token string) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	c.AuthStore = NewTokenAuth(token)
+	c.mu.Unlock()
+
+	c.authChanged(false)
 }
 
 // WithAuthStrategy sets a custom auth strategy to the client.
@@ -276,33 +380,34 @@ func (c *Client) WithToken(token string) {
 // in memory, or when you have a custom token refresh flow.
 func (c *Client) WithAuthStrategy(strategy AuthStrategy) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	if c.AuthStore != nil {
 		c.AuthStore.Clear()
 	}
 	if strategy == nil {
-		c.AuthStore = &NilAuth{}
-		return
+		strategy = &NilAuth{}
 	}
 	c.AuthStore = strategy
+	c.mu.Unlock()
+
+	c.authChanged(false)
 }
 
 // UseAuthResponse receives an AuthResponse and sets the client authentication state.
 func (c *Client) UseAuthResponse(res *AuthResponse) *Client {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	cleared := res == nil || res.Token == ""
 
-	if res == nil || res.Token == "" {
+	c.mu.Lock()
+	if cleared {
 		if c.AuthStore != nil {
 			c.AuthStore.Clear()
 		}
 		c.AuthStore = &NilAuth{}
-		return c
+	} else {
+		c.AuthStore = NewTokenAuth(res.Token)
 	}
+	c.mu.Unlock()
 
-	c.AuthStore = NewTokenAuth(res.Token)
-
+	c.authChanged(cleared)
 	return c
 }
 
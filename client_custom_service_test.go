@@ -50,3 +50,32 @@ func TestClientAllowsCustomRecordService(t *testing.T) {
 		t.Fatalf("custom service not set")
 	}
 }
+
+// mockRealtimeService implements only the required Subscribe method.
+type mockRealtimeService struct {
+	topics []string
+}
+
+func (m *mockRealtimeService) Subscribe(ctx context.Context, topics []string, callback RealtimeCallback) (UnsubscribeFunc, error) {
+	m.topics = append(m.topics, topics...)
+	return func() {}, nil
+}
+
+// TestClientAllowsCustomRealtimeService tests that typed subscriptions work
+// with a realtime service that doesn't support subscription options.
+func TestClientAllowsCustomRealtimeService(t *testing.T) {
+	c := NewClient("http://example.com")
+	mock := &mockRealtimeService{}
+	c.Realtime = mock
+	posts := NewTypedRecordService[testPost](c, "posts")
+
+	if _, err := posts.Subscribe(context.Background(), "*", func(TypedRealtimeEvent[testPost], error) {}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mock.topics) != 1 || mock.topics[0] != "posts/*" {
+		t.Fatalf("unexpected topics: %v", mock.topics)
+	}
+	if _, err := posts.SubscribeWithOptions(context.Background(), "*", &SubscribeOptions{Expand: "author"}, func(TypedRealtimeEvent[testPost], error) {}); err == nil {
+		t.Fatal("expected an error for unsupported subscription options")
+	}
+}
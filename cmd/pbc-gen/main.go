@@ -120,6 +120,7 @@ func main() {
 				GetterMethod: getter, // Assign value to the newly added GetterMethod field.
 				IsPointer:    isPointer,
 				BaseType:     baseType,
+				IsFile:       field.Type == "file",
 				ToMapBlock:   generator.BuildToMapBlock(field.Name, goName, !field.Required),
 				ValueOrBlock: generator.BuildValueOrBlock(collectionData.StructName, goName, field.Name, baseType, isPointer),
 			})
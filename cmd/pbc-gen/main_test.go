@@ -217,10 +217,52 @@ func TestCLIIntegration(t *testing.T) {
 			if !strings.Contains(contentStr, "type TestItems struct") {
 				t.Error("기본 구조체가 생성되지 않았습니다")
 			}
+
+			// 부분 업데이트용 Patch 타입도 생성되어야 함
+			if !strings.Contains(contentStr, "type TestItemsPatch struct") ||
+				!strings.Contains(contentStr, "func (p *TestItemsPatch) SetName(v string) *TestItemsPatch") {
+				t.Error("Patch 타입이 생성되지 않았습니다")
+			}
 		})
 	}
 }
 
+// TestCLIFilePatchModifiers는 파일 필드에 Remove 수정자만 생성되는지 테스트합니다
+func TestCLIFilePatchModifiers(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "models.gen.go")
+	cmd := exec.Command("go", "run", ".", "-schema", "../../internal/generator/testdata/complex_schema.json", "-path", outputPath)
+	cmd.Dir = "."
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("CLI 실행 실패: %v\nStderr: %s", err, stderr.String())
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("생성된 파일 읽기 실패: %v", err)
+	}
+	contentStr := string(content)
+
+	// 다중 파일 필드(attachments)는 Remove만 지원
+	if !strings.Contains(contentStr, "func (p *PostsPatch) RemoveAttachments(") {
+		t.Error("파일 필드의 Remove 메서드가 생성되지 않았습니다")
+	}
+	for _, name := range []string{"AppendAttachments", "PrependAttachments"} {
+		if strings.Contains(contentStr, name) {
+			t.Errorf("파일 필드에 %s가 생성되었습니다", name)
+		}
+	}
+
+	// 일반 []string 필드(tags)는 모든 수정자를 유지
+	for _, name := range []string{"AppendTags", "PrependTags", "RemoveTags"} {
+		if !strings.Contains(contentStr, "func (p *PostsPatch) "+name+"(") {
+			t.Errorf("%s가 생성되지 않았습니다", name)
+		}
+	}
+}
+
 // TestCLIErrorHandling은 CLI의 에러 처리를 테스트합니다
 func TestCLIErrorHandling(t *testing.T) {
 	tempDir := t.TempDir()
@@ -268,6 +268,49 @@ func (m *{{$collection.StructName}}) ToMap() map[string]any {
 	{{- end}}
 	return data
 }
+
+// {{$collection.StructName}}Patch builds a partial update of {{$collection.StructName}} with field modifiers.
+// Pass it to Update or to the typed service's ApplyPatch.
+type {{$collection.StructName}}Patch struct {
+	pocketbase.Patch
+}
+
+func New{{$collection.StructName}}Patch() *{{$collection.StructName}}Patch {
+	return &{{$collection.StructName}}Patch{}
+}
+{{range .Fields}}
+{{- if .IsPointer}}
+func (p *{{$collection.StructName}}Patch) Set{{.GoName}}(v {{.BaseType}}) *{{$collection.StructName}}Patch { p.Set("{{.JSONName}}", v); return p }
+{{- else}}
+func (p *{{$collection.StructName}}Patch) Set{{.GoName}}(v {{.GoType}}) *{{$collection.StructName}}Patch { p.Set("{{.JSONName}}", v); return p }
+{{- end}}
+{{- if eq .GoType "[]string"}}
+{{- if not .IsFile}}
+func (p *{{$collection.StructName}}Patch) Append{{.GoName}}(v ...string) *{{$collection.StructName}}Patch {
+	for _, s := range v {
+		p.Append("{{.JSONName}}", s)
+	}
+	return p
+}
+func (p *{{$collection.StructName}}Patch) Prepend{{.GoName}}(v ...string) *{{$collection.StructName}}Patch {
+	for _, s := range v {
+		p.Prepend("{{.JSONName}}", s)
+	}
+	return p
+}
+{{- end}}
+func (p *{{$collection.StructName}}Patch) Remove{{.GoName}}(v ...string) *{{$collection.StructName}}Patch {
+	for _, s := range v {
+		p.Remove("{{.JSONName}}", s)
+	}
+	return p
+}
+{{- end}}
+{{- if eq .BaseType "float64"}}
+func (p *{{$collection.StructName}}Patch) Increment{{.GoName}}(n float64) *{{$collection.StructName}}Patch { p.Increment("{{.JSONName}}", n); return p }
+func (p *{{$collection.StructName}}Patch) Decrement{{.GoName}}(n float64) *{{$collection.StructName}}Patch { p.Decrement("{{.JSONName}}", n); return p }
+{{- end}}
+{{- end}}
 {{end}}
 
 // ==============
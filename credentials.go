@@ -0,0 +1,113 @@
+package pocketbase
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// CredentialSource supplies the identity and password used by PasswordAuth.
+// It is consulted only when a token has to be acquired or refreshed, so
+// implementations can keep secrets outside of the process memory and
+// rotate them without restarting the service.
+type CredentialSource interface {
+	Credentials(ctx context.Context) (identity, password string, err error)
+}
+
+// CredentialFunc adapts a function to the CredentialSource interface.
+type CredentialFunc func(ctx context.Context) (identity, password string, err error)
+
+// Credentials calls f(ctx).
+func (f CredentialFunc) Credentials(ctx context.Context) (string, string, error) {
+	return f(ctx)
+}
+
+// StaticCredentials returns a CredentialSource that always returns the given values.
+// The password is kept in memory; prefer EnvCredentials or FileCredentials
+// for long-running services.
+func StaticCredentials(identity, password string) CredentialSource {
+	return CredentialFunc(func(ctx context.Context) (string, string, error) {
+		return identity, password, nil
+	})
+}
+
+// EnvCredentials reads the identity and password from environment variables
+// each time credentials are requested.
+type EnvCredentials struct {
+	// Identity is used when IdentityVar is empty.
+	Identity    string
+	IdentityVar string
+	PasswordVar string
+}
+
+var _ CredentialSource = (*EnvCredentials)(nil)
+
+// NewEnvCredentials creates an EnvCredentials source for the given variable names.
+func NewEnvCredentials(identityVar, passwordVar string) *EnvCredentials {
+	return &EnvCredentials{IdentityVar: identityVar, PasswordVar: passwordVar}
+}
+
+// Credentials looks up the configured environment variables.
+func (e *EnvCredentials) Credentials(ctx context.Context) (string, string, error) {
+	identity := e.Identity
+	if e.IdentityVar != "" {
+		v, ok := os.LookupEnv(e.IdentityVar)
+		if !ok || v == "" {
+			return "", "", fmt.Errorf("pocketbase: environment variable %s is not set", e.IdentityVar)
+		}
+		identity = v
+	}
+	password, ok := os.LookupEnv(e.PasswordVar)
+	if !ok || password == "" {
+		return "", "", fmt.Errorf("pocketbase: environment variable %s is not set", e.PasswordVar)
+	}
+	return identity, password, nil
+}
+
+// FileCredentials reads the identity and password from files, such as
+// Docker or Kubernetes secrets. The files are read on every request, so
+// rotated secrets are picked up on the next token refresh.
+// Trailing newlines are trimmed.
+type FileCredentials struct {
+	// Identity is used when IdentityFile is empty.
+	Identity     string
+	IdentityFile string
+	PasswordFile string
+}
+
+var _ CredentialSource = (*FileCredentials)(nil)
+
+// NewFileCredentials creates a FileCredentials source for the given file paths.
+func NewFileCredentials(identityFile, passwordFile string) *FileCredentials {
+	return &FileCredentials{IdentityFile: identityFile, PasswordFile: passwordFile}
+}
+
+// Credentials reads the configured files.
+func (f *FileCredentials) Credentials(ctx context.Context) (string, string, error) {
+	identity := f.Identity
+	if f.IdentityFile != "" {
+		v, err := readSecretFile(f.IdentityFile)
+		if err != nil {
+			return "", "", err
+		}
+		identity = v
+	}
+	password, err := readSecretFile(f.PasswordFile)
+	if err != nil {
+		return "", "", err
+	}
+	return identity, password, nil
+}
+
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("pocketbase: read secret file: %w", err)
+	}
+	v := strings.TrimRight(string(data), "\r\n")
+	if v == "" {
+		return "", fmt.Errorf("pocketbase: secret file %s is empty", path)
+	}
+	return v, nil
+}
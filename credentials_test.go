@@ -0,0 +1,112 @@
+package pocketbase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+func TestEnvCredentials(t *testing.T) {
+	t.Setenv("PB_TEST_IDENTITY", "svc@example.com")
+	t.Setenv("PB_TEST_PASSWORD", "s3cret")
+
+	identity, password, err := NewEnvCredentials("PB_TEST_IDENTITY", "PB_TEST_PASSWORD").Credentials(context.Background())
+	if err != nil {
+		t.Fatalf("Credentials failed: %v", err)
+	}
+	if identity != "svc@example.com" || password != "s3cret" {
+		t.Fatalf("unexpected credentials: %q %q", identity, password)
+	}
+
+	_, _, err = NewEnvCredentials("PB_TEST_IDENTITY", "PB_TEST_MISSING").Credentials(context.Background())
+	if err == nil {
+		t.Fatal("expected error for missing variable")
+	}
+}
+
+func TestFileCredentials(t *testing.T) {
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "password")
+	if err := os.WriteFile(passwordFile, []byte("first\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	src := &FileCredentials{Identity: "svc@example.com", PasswordFile: passwordFile}
+	identity, password, err := src.Credentials(context.Background())
+	if err != nil {
+		t.Fatalf("Credentials failed: %v", err)
+	}
+	if identity != "svc@example.com" || password != "first" {
+		t.Fatalf("unexpected credentials: %q %q", identity, password)
+	}
+
+	// Rotated secrets must be picked up without re-creating the source.
+	if err := os.WriteFile(passwordFile, []byte("second"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, password, err = src.Credentials(context.Background())
+	if err != nil {
+		t.Fatalf("Credentials failed: %v", err)
+	}
+	if password != "second" {
+		t.Fatalf("expected rotated password, got %q", password)
+	}
+
+	if _, _, err := NewFileCredentials("", filepath.Join(dir, "missing")).Credentials(context.Background()); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
+
+func TestPasswordAuth_CredentialSource(t *testing.T) {
+	tokenString := newTestJWT(t, time.Now().Add(10*time.Second))
+	var received []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		received = append(received, body["password"])
+		fmt.Fprintf(w, `{"token":"%s","record":{"id":"user1"}}`, tokenString)
+	}))
+	defer srv.Close()
+
+	calls := 0
+	src := CredentialFunc(func(ctx context.Context) (string, string, error) {
+		calls++
+		return "svc@example.com", fmt.Sprintf("pw%d", calls), nil
+	})
+
+	c := NewClient(srv.URL)
+	if _, err := c.WithCredentialSource(context.Background(), "users", src); err != nil {
+		t.Fatalf("WithCredentialSource failed: %v", err)
+	}
+	// The token is inside the expiry leeway, so the next call refreshes
+	// and must consult the source again.
+	if _, err := c.AuthStore.Token(c); err != nil {
+		t.Fatalf("Token failed: %v", err)
+	}
+
+	if len(received) != 2 || received[0] != "pw1" || received[1] != "pw2" {
+		t.Fatalf("expected source to be consulted on each refresh, got %v", received)
+	}
+}
+
+func TestPasswordAuth_CredentialSourceError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request: %s", r.URL.Path)
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	auth := NewPasswordAuthWithSource(c, "users", CredentialFunc(func(ctx context.Context) (string, string, error) {
+		return "", "", fmt.Errorf("vault sealed")
+	}))
+	if _, err := auth.Token(c); err == nil {
+		t.Fatal("expected credential source error")
+	}
+}
@@ -0,0 +1,347 @@
+package pocketbase
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// FilterExpr is a PocketBase filter expression built from F, Filter, And and Or.
+// Values are quoted safely, so user input can't change the expression.
+//
+// An expression that could not be built (for example from an invalid field
+// name) reports the reason in Err, and String returns a deliberately
+// malformed filter so that the server rejects the request instead of
+// silently matching every record.
+type FilterExpr struct {
+	expr string
+	err  error
+}
+
+// String returns the expression, ready for ListOptions.Filter.
+func (e FilterExpr) String() string {
+	if e.err != nil {
+		return "("
+	}
+	return e.expr
+}
+
+// Err returns the error that occurred while building the expression, if any.
+func (e FilterExpr) Err() error {
+	return e.err
+}
+
+// Build returns the expression or the error that occurred while building it.
+func (e FilterExpr) Build() (string, error) {
+	return e.expr, e.err
+}
+
+// And combines e and others with &&.
+func (e FilterExpr) And(others ...FilterExpr) FilterExpr {
+	return And(append([]FilterExpr{e}, others...)...)
+}
+
+// Or combines e and others with ||.
+func (e FilterExpr) Or(others ...FilterExpr) FilterExpr {
+	return Or(append([]FilterExpr{e}, others...)...)
+}
+
+// And combines exprs with &&, grouping each in parentheses. Empty
+// expressions are skipped.
+func And(exprs ...FilterExpr) FilterExpr {
+	return joinFilters(" && ", exprs)
+}
+
+// Or combines exprs with ||, grouping each in parentheses. Empty
+// expressions are skipped.
+func Or(exprs ...FilterExpr) FilterExpr {
+	return joinFilters(" || ", exprs)
+}
+
+func joinFilters(op string, exprs []FilterExpr) FilterExpr {
+	var parts []string
+	for _, e := range exprs {
+		if e.err != nil {
+			return e
+		}
+		if e.expr != "" {
+			parts = append(parts, e.expr)
+		}
+	}
+	if len(parts) == 1 {
+		return FilterExpr{expr: parts[0]}
+	}
+	for i, p := range parts {
+		parts[i] = "(" + p + ")"
+	}
+	return FilterExpr{expr: strings.Join(parts, op)}
+}
+
+// FilterField is a field, macro or modifier path on the left or right side of
+// a filter condition. Values of type FilterField are inserted unquoted.
+type FilterField string
+
+// Datetime macros, usable as values in filter conditions.
+const (
+	MacroNow        FilterField = "@now"
+	MacroYesterday  FilterField = "@yesterday"
+	MacroTomorrow   FilterField = "@tomorrow"
+	MacroSecond     FilterField = "@second"
+	MacroMinute     FilterField = "@minute"
+	MacroHour       FilterField = "@hour"
+	MacroWeekday    FilterField = "@weekday"
+	MacroDay        FilterField = "@day"
+	MacroMonth      FilterField = "@month"
+	MacroYear       FilterField = "@year"
+	MacroTodayStart FilterField = "@todayStart"
+	MacroTodayEnd   FilterField = "@todayEnd"
+	MacroMonthStart FilterField = "@monthStart"
+	MacroMonthEnd   FilterField = "@monthEnd"
+	MacroYearStart  FilterField = "@yearStart"
+	MacroYearEnd    FilterField = "@yearEnd"
+)
+
+// filterFieldPattern matches field paths such as "title", "author.name",
+// "@request.auth.id", "@collection.posts.id" or "tags:each".
+var filterFieldPattern = regexp.MustCompile(`^@?[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z0-9_@]+)*(:[a-z]+)?$`)
+
+// F starts a condition on field, e.g. F("status").Eq("published").
+func F(field string) FilterField {
+	return FilterField(field)
+}
+
+// Each applies the :each modifier, matching every item of a multi-value field.
+func (f FilterField) Each() FilterField { return f + ":each" }
+
+// Length applies the :length modifier, comparing the number of items.
+func (f FilterField) Length() FilterField { return f + ":length" }
+
+// Lower applies the :lower modifier for case-insensitive comparisons.
+func (f FilterField) Lower() FilterField { return f + ":lower" }
+
+// Eq builds "f = value".
+func (f FilterField) Eq(value any) FilterExpr { return f.op("=", value) }
+
+// NotEq builds "f != value".
+func (f FilterField) NotEq(value any) FilterExpr { return f.op("!=", value) }
+
+// Gt builds "f > value".
+func (f FilterField) Gt(value any) FilterExpr { return f.op(">", value) }
+
+// Gte builds "f >= value".
+func (f FilterField) Gte(value any) FilterExpr { return f.op(">=", value) }
+
+// Lt builds "f < value".
+func (f FilterField) Lt(value any) FilterExpr { return f.op("<", value) }
+
+// Lte builds "f <= value".
+func (f FilterField) Lte(value any) FilterExpr { return f.op("<=", value) }
+
+// Like builds "f ~ value". Without % wildcards the value is matched anywhere.
+func (f FilterField) Like(value any) FilterExpr { return f.op("~", value) }
+
+// NotLike builds "f !~ value".
+func (f FilterField) NotLike(value any) FilterExpr { return f.op("!~", value) }
+
+// AnyEq builds "f ?= value", matching if any item of a multi-value field equals value.
+func (f FilterField) AnyEq(value any) FilterExpr { return f.op("?=", value) }
+
+// AnyNotEq builds "f ?!= value".
+func (f FilterField) AnyNotEq(value any) FilterExpr { return f.op("?!=", value) }
+
+// AnyGt builds "f ?> value".
+func (f FilterField) AnyGt(value any) FilterExpr { return f.op("?>", value) }
+
+// AnyGte builds "f ?>= value".
+func (f FilterField) AnyGte(value any) FilterExpr { return f.op("?>=", value) }
+
+// AnyLt builds "f ?< value".
+func (f FilterField) AnyLt(value any) FilterExpr { return f.op("?<", value) }
+
+// AnyLte builds "f ?<= value".
+func (f FilterField) AnyLte(value any) FilterExpr { return f.op("?<=", value) }
+
+// AnyLike builds "f ?~ value".
+func (f FilterField) AnyLike(value any) FilterExpr { return f.op("?~", value) }
+
+// AnyNotLike builds "f ?!~ value".
+func (f FilterField) AnyNotLike(value any) FilterExpr { return f.op("?!~", value) }
+
+// In matches any of values, as "f = v1 || f = v2 ...". Without values the
+// expression matches nothing.
+func (f FilterField) In(values ...any) FilterExpr {
+	if len(values) == 0 {
+		return FilterExpr{expr: "1 = 0"}
+	}
+	exprs := make([]FilterExpr, len(values))
+	for i, v := range values {
+		exprs[i] = f.Eq(v)
+	}
+	return Or(exprs...)
+}
+
+func (f FilterField) op(op string, value any) FilterExpr {
+	if err := f.validate(); err != nil {
+		return FilterExpr{err: err}
+	}
+	v, err := quoteFilterValue(value)
+	if err != nil {
+		return FilterExpr{err: err}
+	}
+	return FilterExpr{expr: string(f) + " " + op + " " + v}
+}
+
+func (f FilterField) validate() error {
+	if !filterFieldPattern.MatchString(string(f)) {
+		return fmt.Errorf("pocketbase: invalid filter field %q", string(f))
+	}
+	return nil
+}
+
+// filterParamPattern matches {:name} placeholders.
+var filterParamPattern = regexp.MustCompile(`\{:(\w+)\}`)
+
+// Filter replaces the {:name} placeholders in expr with the quoted values of
+// params, like the JS SDK's pb.filter:
+//
+//	pocketbase.Filter("title ~ {:title} && created >= {:since}", map[string]any{
+//		"title": input,
+//		"since": time.Now().Add(-24 * time.Hour),
+//	})
+//
+// Strings, numbers, bools, time.Time, types.DateTime and nil are supported;
+// other values are encoded as JSON strings. A missing parameter is an error.
+// Placeholders inside quoted string literals are left as they are.
+func Filter(expr string, params map[string]any) FilterExpr {
+	var err error
+	out := replaceFilterParams(expr, func(m string) string {
+		name := m[2 : len(m)-1]
+		value, ok := params[name]
+		if !ok {
+			if err == nil {
+				err = fmt.Errorf("pocketbase: missing filter parameter %q", name)
+			}
+			return m
+		}
+		quoted, qerr := quoteFilterValue(value)
+		if qerr != nil && err == nil {
+			err = qerr
+		}
+		return quoted
+	})
+	if err != nil {
+		return FilterExpr{err: err}
+	}
+	return FilterExpr{expr: out}
+}
+
+// replaceFilterParams applies replace to the placeholders of expr outside of
+// '...' and "..." literals, in which a backslash escapes the next character.
+func replaceFilterParams(expr string, replace func(string) string) string {
+	var b strings.Builder
+	start := 0 // start of the current unquoted span
+	for i := 0; i < len(expr); i++ {
+		quote := expr[i]
+		if quote != '\'' && quote != '"' {
+			continue
+		}
+		b.WriteString(filterParamPattern.ReplaceAllStringFunc(expr[start:i], replace))
+		end := i + 1
+		for end < len(expr) && expr[end] != quote {
+			if expr[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		end++ // include the closing quote
+		if end > len(expr) {
+			end = len(expr)
+		}
+		b.WriteString(expr[i:end])
+		start, i = end, end-1
+	}
+	b.WriteString(filterParamPattern.ReplaceAllStringFunc(expr[start:], replace))
+	return b.String()
+}
+
+// quoteFilterValue renders value as a filter literal.
+func quoteFilterValue(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "null", nil
+	case FilterField:
+		if err := v.validate(); err != nil {
+			return "", err
+		}
+		return string(v), nil
+	case string:
+		return quoteFilterString(v)
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int8:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float32:
+		if err := checkFiniteFilterValue(float64(v)); err != nil {
+			return "", err
+		}
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case float64:
+		if err := checkFiniteFilterValue(v); err != nil {
+			return "", err
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case time.Time:
+		return quoteFilterString(v.UTC().Format(types.DefaultDateLayout))
+	case types.DateTime:
+		return quoteFilterString(v.String())
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("pocketbase: unsupported filter value %T: %w", value, err)
+	}
+	return quoteFilterString(string(data))
+}
+
+// checkFiniteFilterValue rejects NaN and infinities, which have no literal in
+// the filter syntax.
+func checkFiniteFilterValue(v float64) error {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return fmt.Errorf("pocketbase: filter value %v is not a finite number", v)
+	}
+	return nil
+}
+
+// quoteFilterString quotes s with single quotes. The filter syntax only
+// escapes quotes, so a trailing backslash would escape the closing quote and
+// can't be represented.
+func quoteFilterString(s string) (string, error) {
+	if strings.HasSuffix(s, `\`) {
+		return "", fmt.Errorf("pocketbase: filter value %q ends with a backslash", s)
+	}
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'", nil
+}
@@ -0,0 +1,97 @@
+package pocketbase
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilterBuilder(t *testing.T) {
+	tests := []struct {
+		name string
+		expr FilterExpr
+		want string
+	}{
+		{"string", F("name").Eq("O'Brien"), `name = 'O\'Brien'`},
+		{"injection", F("name").Eq("x' || 1=1 || '"), `name = 'x\' || 1=1 || \''`},
+		{"number", F("views").Gte(10), `views >= 10`},
+		{"float", F("score").Lt(0.5), `score < 0.5`},
+		{"bool", F("published").Eq(true), `published = true`},
+		{"nil", F("deleted").NotEq(nil), `deleted != null`},
+		{"time", F("created").Gt(time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("KST", 9*3600))), `created > '2024-05-01 03:00:00.000Z'`},
+		{"macro", F("created").Lte(MacroNow), `created <= @now`},
+		{"relative macros", F("due").Gte(MacroYesterday).And(F("due").Lt(MacroTomorrow)), `(due >= @yesterday) && (due < @tomorrow)`},
+		{"field", F("author").Eq(F("@request.auth.id")), `author = @request.auth.id`},
+		{"any", F("tags").AnyLike("go"), `tags ?~ 'go'`},
+		{"each", F("tags").Each().NotLike("spam"), `tags:each !~ 'spam'`},
+		{"length", F("tags").Length().Gt(2), `tags:length > 2`},
+		{"and", And(F("a").Eq(1), F("b").Eq(2)), `(a = 1) && (b = 2)`},
+		{"or in and", F("a").Eq(1).And(F("b").Eq(2).Or(F("c").Eq(3))), `(a = 1) && ((b = 2) || (c = 3))`},
+		{"skip empty", And(FilterExpr{}, F("a").Eq(1)), `a = 1`},
+		{"in", F("status").In("draft", "review"), `(status = 'draft') || (status = 'review')`},
+		{"in none", F("status").In(), `1 = 0`},
+		{"json", F("meta").Eq(map[string]int{"a": 1}), `meta = '{"a":1}'`},
+	}
+	for _, tt := range tests {
+		got, err := tt.expr.Build()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFilterBuilderInvalid(t *testing.T) {
+	for _, expr := range []FilterExpr{
+		F("name = 'x' || id").Eq(1),
+		F("name").Eq(`trailing\`),
+		F("a").Eq(1).And(F("b c").Eq(2)),
+		Filter("title = {:title}", nil),
+		F("score").Gt(math.NaN()),
+		F("score").Lt(math.Inf(1)),
+		F("score").Eq(float32(math.Inf(-1))),
+	} {
+		if expr.Err() == nil {
+			t.Errorf("expected an error for %q", expr.String())
+			continue
+		}
+		// Invalid expressions must never turn into an empty (match-all) filter.
+		if expr.String() == "" {
+			t.Error("invalid expression rendered as an empty filter")
+		}
+	}
+}
+
+func TestFilterParams(t *testing.T) {
+	expr := Filter("title ~ {:title} && views > {:views} && author = {:me} && {:title} != ''", map[string]any{
+		"title": "it's",
+		"views": 3,
+		"me":    F("@request.auth.id"),
+	})
+	got, err := expr.Build()
+	if err != nil {
+		t.Fatalf("Filter failed: %v", err)
+	}
+	want := `title ~ 'it\'s' && views > 3 && author = @request.auth.id && 'it\'s' != ''`
+	if got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+	// Placeholders inside string literals are not parameters.
+	got, err = Filter(`title = "{:x}" && note != 'it\'s {:x}' && id = {:x}`, map[string]any{"x": "a"}).Build()
+	if err != nil {
+		t.Fatalf("Filter failed: %v", err)
+	}
+	if want := `title = "{:x}" && note != 'it\'s {:x}' && id = 'a'`; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+	if err := Filter(`title = '{:missing}'`, nil).Err(); err != nil {
+		t.Fatalf("a quoted placeholder should not be required: %v", err)
+	}
+	if err := Filter("id = {:id}", map[string]any{}).Err(); err == nil || !strings.Contains(err.Error(), `"id"`) {
+		t.Fatalf("expected missing parameter error, got %v", err)
+	}
+}
@@ -32,6 +32,7 @@ type FieldData struct {
 	GetterMethod string // Getter method name (e.g., GetString, GetBool)
 	IsPointer    bool   // Whether this field is a pointer type (for ValueOr method generation)
 	BaseType     string // Base type without pointer (e.g., 'string' for '*string')
+	IsFile       bool   // Whether this is a file field (only removal modifiers apply)
 	ToMapBlock   string // Preformatted ToMap field block
 	ValueOrBlock string // Preformatted ValueOr method block (empty for non-pointer fields)
 }
@@ -98,6 +98,7 @@ func BuildTemplateData(schemas []CollectionSchema, packageName string) TemplateD
 				GetterMethod: getterMethod,
 				IsPointer:    isPointer,
 				BaseType:     baseType,
+				IsFile:       f.Type == "file",
 				ToMapBlock:   BuildToMapBlock(f.Name, goName, !f.Required),
 				ValueOrBlock: BuildValueOrBlock(structName, goName, f.Name, baseType, isPointer),
 			})
@@ -0,0 +1,204 @@
+package pocketbase
+
+import (
+	"context"
+	"iter"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// iteratePerPage is the page size of iterators when ListOptions.PerPage is unset.
+const iteratePerPage = 100
+
+// listPage fetches one page for an iterator and returns its items, the page
+// size the server applied and the total number of pages (unless skipped).
+type listPage[T any] func(ctx context.Context, opts *ListOptions) (items []*T, perPage, totalPages int, err error)
+
+// iteratePages yields the items of every page starting at opts.Page, fetching
+// pages lazily. Iteration stops at the first error, which is yielded with a
+// nil item, or when the consumer breaks out of the loop.
+//
+// Sequential iteration skips the total count; the last page is the first one
+// shorter than the page size. With opts.Concurrency > 1 the first page is
+// requested with totals and the following pages are prefetched in parallel.
+func iteratePages[T any](ctx context.Context, opts *ListOptions, fetch listPage[T]) iter.Seq2[*T, error] {
+	var o ListOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Page < 1 {
+		o.Page = 1
+	}
+	if o.PerPage <= 0 {
+		o.PerPage = iteratePerPage
+	}
+	if o.Concurrency > 1 {
+		return iteratePagesConcurrently(ctx, o, fetch)
+	}
+	o.SkipTotal = true
+
+	return func(yield func(*T, error) bool) {
+		page := o
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+			items, perPage, _, err := fetch(ctx, &page)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if perPage <= 0 {
+				perPage = page.PerPage
+			}
+			if len(items) == 0 || len(items) < perPage {
+				return
+			}
+			page.Page++
+		}
+	}
+}
+
+// prefetchedPage is a page requested ahead of the consumer.
+type prefetchedPage[T any] struct {
+	done  chan struct{}
+	items []*T
+	err   error
+}
+
+// iteratePagesConcurrently fetches the first page with totals, then keeps up
+// to o.Concurrency of the following pages in flight and yields them in order.
+// The first failing request cancels the others.
+func iteratePagesConcurrently[T any](ctx context.Context, o ListOptions, fetch listPage[T]) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		if err := ctx.Err(); err != nil {
+			yield(nil, err)
+			return
+		}
+		first := o
+		first.SkipTotal = false
+		items, _, totalPages, err := fetch(ctx, &first)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		g, gctx := errgroup.WithContext(ctx)
+		defer func() {
+			cancel()
+			_ = g.Wait()
+		}()
+
+		pages := make(map[int]*prefetchedPage[T])
+		next := o.Page + 1
+		start := func() {
+			for next <= totalPages && len(pages) < o.Concurrency {
+				p := &prefetchedPage[T]{done: make(chan struct{})}
+				pages[next] = p
+				page := o
+				page.Page = next
+				page.SkipTotal = true
+				g.Go(func() error {
+					defer close(p.done)
+					p.items, _, _, p.err = fetch(gctx, &page)
+					return p.err
+				})
+				next++
+			}
+		}
+
+		for current := o.Page; ; current++ {
+			start()
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if current >= totalPages {
+				return
+			}
+			p := pages[current+1]
+			<-p.done
+			delete(pages, current+1)
+			if p.err != nil {
+				yield(nil, g.Wait())
+				return
+			}
+			items = p.items
+		}
+	}
+}
+
+// Iterate returns an iterator over all records of collection matching opts,
+// starting at opts.Page. Pages are fetched lazily as the loop advances:
+//
+//	for rec, err := range client.Records.Iterate(ctx, "posts", nil) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(rec.ID)
+//	}
+func (s *RecordService) Iterate(ctx context.Context, collection string, opts *ListOptions) iter.Seq2[*Record, error] {
+	return iteratePages(ctx, opts, func(ctx context.Context, opts *ListOptions) ([]*Record, int, int, error) {
+		res, err := s.GetList(ctx, collection, opts)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		return res.Items, res.PerPage, res.TotalPages, nil
+	})
+}
+
+// Iterate returns an iterator over all records matching opts, converted to T.
+// See RecordService.Iterate.
+func (s *TypedRecordService[T]) Iterate(ctx context.Context, opts *ListOptions) iter.Seq2[*T, error] {
+	return iteratePages(ctx, opts, func(ctx context.Context, opts *ListOptions) ([]*T, int, int, error) {
+		res, err := s.GetList(ctx, opts)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		return res.Items, res.PerPage, res.TotalPages, nil
+	})
+}
+
+// Iterate returns an iterator over all collections matching opts.
+// See RecordService.Iterate.
+func (s *CollectionService) Iterate(ctx context.Context, opts *ListOptions) iter.Seq2[*Collection, error] {
+	return iteratePages(ctx, opts, func(ctx context.Context, opts *ListOptions) ([]*Collection, int, int, error) {
+		res, err := s.GetList(ctx, opts)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		return res.Items, res.PerPage, res.TotalPages, nil
+	})
+}
+
+// Iterate returns an iterator over all administrators matching opts.
+// See RecordService.Iterate.
+func (s *AdminService) Iterate(ctx context.Context, opts *ListOptions) iter.Seq2[*Record, error] {
+	return iteratePages(ctx, opts, func(ctx context.Context, opts *ListOptions) ([]*Record, int, int, error) {
+		res, err := s.GetList(ctx, opts)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		return res.Items, res.PerPage, res.TotalPages, nil
+	})
+}
+
+// IterateRequests returns an iterator over all request logs matching opts.
+// See RecordService.Iterate.
+func (s *LogService) IterateRequests(ctx context.Context, opts *ListOptions) iter.Seq2[*Record, error] {
+	return iteratePages(ctx, opts, func(ctx context.Context, opts *ListOptions) ([]*Record, int, int, error) {
+		res, err := s.GetRequestsList(ctx, opts)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		return res.Items, res.PerPage, res.TotalPages, nil
+	})
+}
@@ -0,0 +1,258 @@
+package pocketbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// newPagedServer serves total records from any list endpoint, honoring page
+// and perPage. It fails the test if the total count isn't skipped.
+func newPagedServer(t *testing.T, total int, requests *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		q := r.URL.Query()
+		if q.Get("skipTotal") != "1" {
+			t.Errorf("expected skipTotal=1, got %q", r.URL.RawQuery)
+		}
+		page, _ := strconv.Atoi(q.Get("page"))
+		perPage, _ := strconv.Atoi(q.Get("perPage"))
+		res := ListResult{Page: page, PerPage: perPage, TotalItems: -1, TotalPages: -1, Items: []*Record{}}
+		for i := (page - 1) * perPage; i < page*perPage && i < total; i++ {
+			res.Items = append(res.Items, &Record{ID: fmt.Sprintf("r%d", i)})
+		}
+		_ = json.NewEncoder(w).Encode(res)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRecordServiceIterate(t *testing.T) {
+	var requests atomic.Int32
+	srv := newPagedServer(t, 250, &requests)
+	c := NewClient(srv.URL)
+
+	n := 0
+	for rec, err := range c.Records.(*RecordService).Iterate(context.Background(), "posts", nil) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := fmt.Sprintf("r%d", n); rec.ID != want {
+			t.Fatalf("got %s, want %s", rec.ID, want)
+		}
+		n++
+	}
+	if n != 250 {
+		t.Fatalf("got %d records, want 250", n)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Fatalf("got %d requests, want 3", got)
+	}
+}
+
+func TestRecordServiceIterateEarlyBreak(t *testing.T) {
+	var requests atomic.Int32
+	srv := newPagedServer(t, 100, &requests)
+	c := NewClient(srv.URL)
+
+	n := 0
+	for _, err := range c.Records.(*RecordService).Iterate(context.Background(), "posts", &ListOptions{PerPage: 10}) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if n++; n == 15 {
+			break
+		}
+	}
+	if got := requests.Load(); got != 2 {
+		t.Fatalf("got %d requests, want 2", got)
+	}
+}
+
+func TestRecordServiceIterateCanceled(t *testing.T) {
+	var requests atomic.Int32
+	srv := newPagedServer(t, 100, &requests)
+	c := NewClient(srv.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var gotErr error
+	n := 0
+	for _, err := range c.Records.(*RecordService).Iterate(ctx, "posts", &ListOptions{PerPage: 10}) {
+		if err != nil {
+			gotErr = err
+			break
+		}
+		if n++; n == 10 {
+			cancel()
+		}
+	}
+	if !errors.Is(gotErr, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", gotErr)
+	}
+	if n != 10 {
+		t.Fatalf("got %d records, want 10", n)
+	}
+}
+
+func TestRecordServiceIterateError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"status":403,"message":"forbidden"}`))
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL)
+
+	calls := 0
+	for rec, err := range c.Records.(*RecordService).Iterate(context.Background(), "posts", nil) {
+		calls++
+		if rec != nil || err == nil {
+			t.Fatalf("expected an error, got %v, %v", rec, err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("got %d yields, want 1", calls)
+	}
+}
+
+func TestTypedRecordServiceGetAll(t *testing.T) {
+	var requests atomic.Int32
+	srv := newPagedServer(t, 45, &requests)
+	c := NewClient(srv.URL)
+	svc := NewTypedRecordService[testPost](c, "posts")
+
+	all, err := svc.GetAll(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(all) != 45 {
+		t.Fatalf("got %d records, want 45", len(all))
+	}
+
+	srv2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("lang"); got != "en" {
+			t.Errorf("expected query param lang=en, got %q", got)
+		}
+		_ = json.NewEncoder(w).Encode(ListResult{Items: []*Record{}})
+	}))
+	defer srv2.Close()
+	svc = NewTypedRecordService[testPost](NewClient(srv2.URL), "posts")
+	if _, err := svc.GetAll(context.Background(), &ListOptions{QueryParams: map[string]string{"lang": "en"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCollectionServiceIterate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		res := CollectionListResult{Page: page, PerPage: 2, Items: []*Collection{}}
+		if page == 1 {
+			res.Items = []*Collection{{Name: "a"}, {Name: "b"}}
+		} else if page == 2 {
+			res.Items = []*Collection{{Name: "c"}}
+		}
+		_ = json.NewEncoder(w).Encode(res)
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL)
+
+	var names []string
+	for col, err := range c.Collections.(*CollectionService).Iterate(context.Background(), &ListOptions{PerPage: 2}) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		names = append(names, col.Name)
+	}
+	if fmt.Sprint(names) != "[a b c]" {
+		t.Fatalf("unexpected collections: %v", names)
+	}
+}
+
+// newConcurrentPagedServer serves total records with totals, delaying each
+// response so requests overlap, and records the peak number in flight.
+// Requests for page failPage fail.
+func newConcurrentPagedServer(t *testing.T, total, failPage int, peak *atomic.Int32) *httptest.Server {
+	t.Helper()
+	var inFlight atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		q := r.URL.Query()
+		page, _ := strconv.Atoi(q.Get("page"))
+		perPage, _ := strconv.Atoi(q.Get("perPage"))
+		if (page == 1) == (q.Get("skipTotal") == "1") {
+			t.Errorf("page %d: unexpected skipTotal in %q", page, r.URL.RawQuery)
+		}
+		if page == failPage {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"status":400,"message":"boom"}`))
+			return
+		}
+		select {
+		case <-time.After(time.Duration(10*(total/perPage-page+1)) * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+		res := ListResult{Page: page, PerPage: perPage, TotalItems: total, TotalPages: (total + perPage - 1) / perPage, Items: []*Record{}}
+		for i := (page - 1) * perPage; i < page*perPage && i < total; i++ {
+			res.Items = append(res.Items, &Record{ID: fmt.Sprintf("r%d", i)})
+		}
+		_ = json.NewEncoder(w).Encode(res)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRecordServiceIterateConcurrent(t *testing.T) {
+	var peak atomic.Int32
+	srv := newConcurrentPagedServer(t, 95, 0, &peak)
+	c := NewClient(srv.URL)
+
+	n := 0
+	for rec, err := range c.Records.(*RecordService).Iterate(context.Background(), "posts", &ListOptions{PerPage: 10, Concurrency: 3}) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := fmt.Sprintf("r%d", n); rec.ID != want {
+			t.Fatalf("got %s, want %s", rec.ID, want)
+		}
+		n++
+	}
+	if n != 95 {
+		t.Fatalf("got %d records, want 95", n)
+	}
+	if p := peak.Load(); p < 2 || p > 3 {
+		t.Fatalf("got %d requests in flight, want 2 or 3", p)
+	}
+}
+
+func TestRecordServiceIterateConcurrentError(t *testing.T) {
+	var peak atomic.Int32
+	srv := newConcurrentPagedServer(t, 100, 4, &peak)
+	c := NewClient(srv.URL)
+	svc := NewTypedRecordService[testPost](c, "posts")
+
+	all, err := svc.GetAll(context.Background(), &ListOptions{PerPage: 10, Concurrency: 4})
+	if err == nil {
+		t.Fatalf("expected an error, got %d records", len(all))
+	}
+	var pbErr *Error
+	if !errors.As(err, &pbErr) || pbErr.Status != http.StatusBadRequest {
+		t.Fatalf("expected the page 4 error, got %v", err)
+	}
+}
@@ -0,0 +1,433 @@
+package pocketbase
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// defaultPerPage is the page size the server uses when none is requested.
+const defaultPerPage = 30
+
+// LiveQuery keeps the result of a GetList query in sync with realtime events.
+//
+// It subscribes to the collection, then loads the page and patches it as
+// records are created, updated and deleted: membership is re-checked with the
+// query's filter and the sort order is preserved locally for plain field sorts.
+// Whenever a change can't be applied reliably (a relation or random sort, a
+// page other than the first, a gap after reconnecting) the page is re-fetched.
+type LiveQuery struct {
+	records    RecordServiceAPI
+	collection string
+	opts       ListOptions
+	perPage    int
+	// compare orders records like the server; nil if the sort can't be
+	// evaluated locally, in which case every change re-fetches.
+	compare func(a, b *Record) int
+
+	mu    sync.RWMutex
+	items []*Record
+	err   error
+
+	queueMu sync.Mutex
+	queue   []liveQueryOp
+	wake    chan struct{}
+
+	updates   chan struct{}
+	done      chan struct{}
+	cancel    context.CancelFunc // cancels the requests of run
+	closeOnce sync.Once
+
+	unsubscribe UnsubscribeFunc
+}
+
+// liveQueryOp is a queued realtime event, a request to re-fetch or an error.
+type liveQueryOp struct {
+	event   *RealtimeEvent
+	refetch bool
+	err     error
+}
+
+// NewLiveQuery runs the query described by opts against collection and keeps
+// its result up to date until Close is called or ctx is done.
+// opts may be nil.
+func NewLiveQuery(ctx context.Context, client *Client, collection string, opts *ListOptions) (*LiveQuery, error) {
+	q := &LiveQuery{
+		records:    client.Records,
+		collection: collection,
+		perPage:    defaultPerPage,
+		wake:       make(chan struct{}, 1),
+		updates:    make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+	if opts != nil {
+		q.opts = *opts
+	}
+	if q.opts.PerPage > 0 {
+		q.perPage = q.opts.PerPage
+	}
+	if q.opts.Page <= 1 {
+		q.compare = recordComparator(q.opts.Sort)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	q.cancel = cancel
+
+	// Subscribe before loading so that no change between the two is lost.
+	unsubscribe, err := q.subscribe(ctx, client.Realtime)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	q.unsubscribe = unsubscribe
+
+	if err := q.refetch(ctx); err != nil {
+		cancel()
+		unsubscribe()
+		return nil, err
+	}
+	go q.run(runCtx)
+	context.AfterFunc(ctx, q.Close)
+	return q, nil
+}
+
+// subscribe follows the collection's changes. With the built-in realtime
+// service the query stops when reconnecting is given up; other
+// implementations must implement SubscribeWithOptions and report such
+// errors through Err only.
+func (q *LiveQuery) subscribe(ctx context.Context, realtime RealtimeServiceAPI) (UnsubscribeFunc, error) {
+	topics := []string{q.collection + "/*"}
+	opts := &SubscribeOptions{
+		Expand:  q.opts.Expand,
+		Fields:  q.opts.Fields,
+		Query:   q.opts.QueryParams,
+		CatchUp: true,
+	}
+	rs, ok := realtime.(*RealtimeService)
+	if !ok {
+		return subscribeWithOptions(ctx, realtime, topics, opts, q.handle)
+	}
+	l, err := rs.newOptionsListener(topics, opts, q.handle)
+	if err != nil {
+		return nil, err
+	}
+	l.onError = q.fail
+	return rs.subscribe(ctx, l)
+}
+
+// Snapshot returns the current result. The records are shared and must not be modified.
+func (q *LiveQuery) Snapshot() []*Record {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return slices.Clone(q.items)
+}
+
+// Updates returns a channel that receives a value whenever the result
+// changed. Notifications are coalesced; call Snapshot to get the result.
+// The channel is closed once the query stops following changes: after Close,
+// when ctx is done or when the realtime connection is given up, in which
+// case Err reports why.
+func (q *LiveQuery) Updates() <-chan struct{} {
+	return q.updates
+}
+
+// Err returns the last error encountered while applying changes, or nil.
+func (q *LiveQuery) Err() error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.err
+}
+
+// Close stops following changes and cancels any request in flight.
+// The last snapshot remains available.
+func (q *LiveQuery) Close() {
+	q.stop(true)
+}
+
+// fail records the error the realtime connection was given up with and
+// stops the query; there is nothing left to unsubscribe from.
+func (q *LiveQuery) fail(err error) {
+	q.mu.Lock()
+	q.err = err
+	q.mu.Unlock()
+	q.stop(false)
+}
+
+func (q *LiveQuery) stop(unsubscribe bool) {
+	q.closeOnce.Do(func() {
+		close(q.done)
+		q.cancel()
+		if unsubscribe {
+			q.unsubscribe()
+		}
+	})
+}
+
+func (q *LiveQuery) closed() bool {
+	select {
+	case <-q.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// handle queues realtime events; it runs on the SSE reader goroutine.
+func (q *LiveQuery) handle(event *RealtimeEvent, err error) {
+	var op liveQueryOp
+	switch {
+	case err == nil:
+		op.event = event
+	case errors.Is(err, ErrRealtimeGap):
+		op.refetch = true // deletes may have been missed
+	default:
+		op.err = err
+	}
+
+	q.queueMu.Lock()
+	q.queue = append(q.queue, op)
+	q.queueMu.Unlock()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run applies queued operations until the query is closed, then closes
+// the updates channel. Only run notifies once it has started.
+func (q *LiveQuery) run(ctx context.Context) {
+	defer close(q.updates)
+	for {
+		select {
+		case <-q.wake:
+		case <-q.done:
+			return
+		}
+
+		q.queueMu.Lock()
+		ops := q.queue
+		q.queue = nil
+		q.queueMu.Unlock()
+
+		refetch := false
+		for _, op := range ops {
+			if op.err != nil {
+				q.setErr(op.err)
+				continue
+			}
+			if op.refetch || q.compare == nil {
+				refetch = true
+				break
+			}
+			if q.apply(ctx, op.event) {
+				refetch = true
+				break
+			}
+		}
+		if refetch {
+			// A re-fetch reflects all queued events, so the rest can be skipped.
+			if err := q.refetch(ctx); err != nil && !q.closed() {
+				q.setErr(err)
+			}
+		}
+	}
+}
+
+// refetch reloads the page.
+func (q *LiveQuery) refetch(ctx context.Context) error {
+	result, err := q.records.GetList(ctx, q.collection, &q.opts)
+	if err != nil {
+		return fmt.Errorf("pocketbase: live query: %w", err)
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed() {
+		return nil
+	}
+	q.items = result.Items
+	q.err = nil
+	q.notify()
+	return nil
+}
+
+// apply patches the result with event. It reports whether the page must be
+// re-fetched instead.
+func (q *LiveQuery) apply(ctx context.Context, event *RealtimeEvent) (refetch bool) {
+	if event == nil || event.Record == nil {
+		return false
+	}
+	record := event.Record
+
+	member := false
+	if event.Action != string(RealtimeActionDelete) {
+		var err error
+		member, err = q.matches(ctx, record.ID)
+		if err != nil {
+			if q.closed() {
+				return false
+			}
+			q.setErr(err)
+			return true
+		}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed() {
+		return false
+	}
+
+	full := len(q.items) >= q.perPage
+	items := slices.DeleteFunc(slices.Clone(q.items), func(r *Record) bool { return r.ID == record.ID })
+	removed := len(items) < len(q.items)
+	if !member {
+		if !removed {
+			return false
+		}
+		// A record left a full page; the next one is only known to the server.
+		if full {
+			return true
+		}
+		q.items = items
+		q.notify()
+		return false
+	}
+
+	items = append(items, record)
+	slices.SortStableFunc(items, q.compare)
+	if full && items[len(items)-1] == record {
+		// The record sorts last on a full page: records the server holds
+		// beyond the page may come first.
+		return true
+	}
+	if len(items) > q.perPage {
+		items = items[:q.perPage]
+	}
+	q.items = items
+	q.notify()
+	return false
+}
+
+// matches reports whether the record is matched by the query's filter.
+func (q *LiveQuery) matches(ctx context.Context, id string) (bool, error) {
+	if q.opts.Filter == "" {
+		return true, nil
+	}
+	result, err := q.records.GetList(ctx, q.collection, &ListOptions{
+		PerPage:     1,
+		Filter:      F("id").Eq(id).And(FilterExpr{expr: q.opts.Filter}).String(),
+		Fields:      "id",
+		SkipTotal:   true,
+		QueryParams: q.opts.QueryParams,
+	})
+	if err != nil {
+		return false, fmt.Errorf("pocketbase: live query: check membership: %w", err)
+	}
+	return len(result.Items) > 0, nil
+}
+
+func (q *LiveQuery) setErr(err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed() {
+		return
+	}
+	q.err = err
+	q.notify()
+}
+
+func (q *LiveQuery) notify() {
+	select {
+	case q.updates <- struct{}{}:
+	default:
+	}
+}
+
+// recordComparator returns a function that orders records by a sort
+// expression such as "-created,title", or nil if the expression uses
+// anything but plain fields (relations, functions, @random, ...).
+// An empty expression keeps the arrival order.
+func recordComparator(sort string) func(a, b *Record) int {
+	type key struct {
+		field string
+		desc  bool
+	}
+	var keys []key
+	for _, part := range strings.Split(sort, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		k := key{field: strings.TrimLeft(part, "+-"), desc: strings.HasPrefix(part, "-")}
+		if k.field == "" || strings.ContainsAny(k.field, ".()@:") {
+			return nil
+		}
+		keys = append(keys, k)
+	}
+
+	return func(a, b *Record) int {
+		for _, k := range keys {
+			c := compareValues(recordValue(a, k.field), recordValue(b, k.field))
+			if k.desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+}
+
+func recordValue(r *Record, field string) any {
+	switch field {
+	case "id":
+		return r.ID
+	case "collectionId":
+		return r.CollectionID
+	case "collectionName":
+		return r.CollectionName
+	}
+	return r.Get(field)
+}
+
+// compareValues orders decoded JSON values. Values of different types are
+// ordered by type: nil, bool, number, string.
+func compareValues(a, b any) int {
+	rank := func(v any) int {
+		switch v.(type) {
+		case nil:
+			return 0
+		case bool:
+			return 1
+		case float64:
+			return 2
+		case string:
+			return 3
+		}
+		return 4
+	}
+	if c := cmp.Compare(rank(a), rank(b)); c != 0 {
+		return c
+	}
+	switch a := a.(type) {
+	case bool:
+		b := b.(bool)
+		switch {
+		case a == b:
+			return 0
+		case !a:
+			return -1
+		}
+		return 1
+	case float64:
+		return cmp.Compare(a, b.(float64))
+	case string:
+		return cmp.Compare(a, b.(string))
+	}
+	return 0
+}
@@ -0,0 +1,339 @@
+package pocketbase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func liveQueryIDs(items []*Record) string {
+	ids := make([]string, len(items))
+	for i, r := range items {
+		ids[i] = r.ID
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestLiveQuery(t *testing.T) {
+	var lists int32
+	submitted := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/collections/posts/records":
+			filter := r.URL.Query().Get("filter")
+			if strings.HasPrefix(filter, "(id = ") {
+				// Membership check: only x is published.
+				if strings.HasPrefix(filter, "(id = 'x')") {
+					_, _ = io.WriteString(w, `{"items":[{"id":"x"}]}`)
+					return
+				}
+				_, _ = io.WriteString(w, `{"items":[]}`)
+				return
+			}
+			atomic.AddInt32(&lists, 1)
+			if filter != "status = 'published'" || r.URL.Query().Get("sort") != "title" {
+				t.Errorf("unexpected query: %s", r.URL.RawQuery)
+			}
+			_, _ = io.WriteString(w, `{"items":[{"id":"a","title":"b"},{"id":"c","title":"d"}]}`)
+		case r.Method == http.MethodGet:
+			w.Header().Set("Content-Type", "text/event-stream")
+			flusher := w.(http.Flusher)
+			_, _ = io.WriteString(w, "event: PB_CONNECT\ndata: {\"clientId\":\"c1\"}\n\n")
+			flusher.Flush()
+			<-submitted
+			time.Sleep(50 * time.Millisecond)
+			for _, ev := range []string{
+				`{"action":"create","record":{"id":"x","title":"a"}}`,
+				`{"action":"update","record":{"id":"c","title":"d"}}`,
+				`{"action":"delete","record":{"id":"a","title":"b"}}`,
+			} {
+				fmt.Fprintf(w, "event: posts/*\ndata: %s\n\n", ev)
+			}
+			flusher.Flush()
+			<-r.Context().Done()
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusNoContent)
+			submitted <- struct{}{}
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q, err := NewLiveQuery(ctx, NewClient(srv.URL), "posts", &ListOptions{Filter: "status = 'published'", Sort: "title"})
+	if err != nil {
+		t.Fatalf("NewLiveQuery failed: %v", err)
+	}
+	defer q.Close()
+
+	if got := liveQueryIDs(q.Snapshot()); got != "a,c" {
+		t.Fatalf("unexpected initial snapshot: %s", got)
+	}
+
+	deadline := time.After(2 * time.Second)
+	for liveQueryIDs(q.Snapshot()) != "x" {
+		select {
+		case <-q.Updates():
+		case <-deadline:
+			t.Fatalf("timed out, snapshot %s (err %v)", liveQueryIDs(q.Snapshot()), q.Err())
+		}
+	}
+	if q.Err() != nil {
+		t.Fatalf("unexpected error: %v", q.Err())
+	}
+	if got := atomic.LoadInt32(&lists); got != 1 {
+		t.Fatalf("changes should be applied without re-fetching, got %d lists", got)
+	}
+}
+
+// liveQueryServer serves a "posts" collection and its realtime stream.
+type liveQueryServer struct {
+	*httptest.Server
+	lists int32
+	// list returns the items of the nth page request. Membership checks
+	// never match.
+	list func(n int32) string
+	// streams holds the events sent on each connection. Every connection
+	// but the last is dropped after its events; further attempts fail.
+	streams [][]string
+	// dropAll drops the last connection as well.
+	dropAll bool
+
+	connections int32
+	submitted   chan struct{}
+}
+
+func newLiveQueryServer(t *testing.T, s *liveQueryServer) *liveQueryServer {
+	t.Helper()
+	s.submitted = make(chan struct{}, 8)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/collections/posts/records":
+			filter := r.URL.Query().Get("filter")
+			switch {
+			case strings.HasPrefix(filter, "(id = "):
+				_, _ = io.WriteString(w, `{"items":[]}`)
+			case strings.Contains(filter, "updated >= "):
+				_, _ = io.WriteString(w, `{"items":[]}`) // nothing to catch up
+			default:
+				_, _ = io.WriteString(w, s.list(atomic.AddInt32(&s.lists, 1)))
+			}
+		case r.Method == http.MethodGet:
+			n := int(atomic.AddInt32(&s.connections, 1))
+			if n > len(s.streams) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			flusher := w.(http.Flusher)
+			fmt.Fprintf(w, "event: PB_CONNECT\ndata: {\"clientId\":\"c%d\"}\n\n", n)
+			flusher.Flush()
+			select {
+			case <-s.submitted:
+			case <-r.Context().Done():
+				return
+			}
+			time.Sleep(50 * time.Millisecond)
+			for _, ev := range s.streams[n-1] {
+				fmt.Fprintf(w, "event: posts/*\ndata: %s\n\n", ev)
+			}
+			flusher.Flush()
+			if n < len(s.streams) || s.dropAll {
+				time.Sleep(50 * time.Millisecond)
+				return // drop the connection
+			}
+			<-r.Context().Done()
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusNoContent)
+			s.submitted <- struct{}{}
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// waitLiveQuery waits until cond holds for q.
+func waitLiveQuery(t *testing.T, q *LiveQuery, cond func() bool) {
+	t.Helper()
+	deadline := time.After(2 * time.Second)
+	for !cond() {
+		select {
+		case <-q.Updates():
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatalf("timed out, snapshot %s (err %v)", liveQueryIDs(q.Snapshot()), q.Err())
+		}
+	}
+}
+
+func TestLiveQueryRecordLeavesFullPage(t *testing.T) {
+	// Once "a" leaves the full page, only the server knows what comes next.
+	srv := newLiveQueryServer(t, &liveQueryServer{
+		streams: [][]string{{
+			`{"action":"update","record":{"id":"a","title":"a"}}`,
+		}},
+		list: func(n int32) string {
+			if n == 1 {
+				return `{"items":[{"id":"a","title":"a"},{"id":"c","title":"c"}]}`
+			}
+			return `{"items":[{"id":"c","title":"c"},{"id":"e","title":"e"}]}`
+		},
+	})
+
+	q, err := NewLiveQuery(context.Background(), NewClient(srv.URL), "posts", &ListOptions{Filter: "status = 'published'", Sort: "title", PerPage: 2})
+	if err != nil {
+		t.Fatalf("NewLiveQuery failed: %v", err)
+	}
+	defer q.Close()
+
+	waitLiveQuery(t, q, func() bool { return liveQueryIDs(q.Snapshot()) == "c,e" })
+	if got := atomic.LoadInt32(&srv.lists); got != 2 {
+		t.Fatalf("expected a re-fetch, got %d lists", got)
+	}
+}
+
+func TestLiveQueryUnsupportedSortRefetches(t *testing.T) {
+	srv := newLiveQueryServer(t, &liveQueryServer{
+		streams: [][]string{{
+			`{"action":"create","record":{"id":"x"}}`,
+		}},
+		list: func(n int32) string {
+			if n == 1 {
+				return `{"items":[{"id":"a"}]}`
+			}
+			return `{"items":[{"id":"x"},{"id":"a"}]}`
+		},
+	})
+
+	q, err := NewLiveQuery(context.Background(), NewClient(srv.URL), "posts", &ListOptions{Sort: "author.name"})
+	if err != nil {
+		t.Fatalf("NewLiveQuery failed: %v", err)
+	}
+	defer q.Close()
+	if q.compare != nil {
+		t.Fatal("a relation sort should not be evaluated locally")
+	}
+
+	waitLiveQuery(t, q, func() bool { return liveQueryIDs(q.Snapshot()) == "x,a" })
+	if got := atomic.LoadInt32(&srv.lists); got != 2 {
+		t.Fatalf("expected a re-fetch, got %d lists", got)
+	}
+}
+
+func TestLiveQueryRefetchesAfterGap(t *testing.T) {
+	// The first connection drops; catch-up reports a gap, as deletes may have
+	// been missed, and the page is re-fetched.
+	srv := newLiveQueryServer(t, &liveQueryServer{
+		streams: [][]string{{}, {}},
+		list: func(n int32) string {
+			if n == 1 {
+				return `{"items":[{"id":"a"},{"id":"b"}]}`
+			}
+			return `{"items":[{"id":"b"}]}`
+		},
+	})
+	c := NewClient(srv.URL, WithRealtimeOptions(RealtimeOptions{
+		Reconnect: ReconnectPolicy{InitialDelay: 10 * time.Millisecond},
+	}))
+
+	q, err := NewLiveQuery(context.Background(), c, "posts", &ListOptions{Sort: "id"})
+	if err != nil {
+		t.Fatalf("NewLiveQuery failed: %v", err)
+	}
+	defer q.Close()
+
+	waitLiveQuery(t, q, func() bool { return liveQueryIDs(q.Snapshot()) == "b" })
+	if q.Err() != nil {
+		t.Fatalf("a gap should not be reported as an error: %v", q.Err())
+	}
+}
+
+func TestLiveQueryUpdatesClosed(t *testing.T) {
+	t.Run("close", func(t *testing.T) {
+		srv := newLiveQueryServer(t, &liveQueryServer{
+			list:    func(int32) string { return `{"items":[]}` },
+			streams: [][]string{{}},
+		})
+		q, err := NewLiveQuery(context.Background(), NewClient(srv.URL), "posts", nil)
+		if err != nil {
+			t.Fatalf("NewLiveQuery failed: %v", err)
+		}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for range q.Updates() {
+			}
+		}()
+		q.Close()
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("Updates was not closed by Close")
+		}
+	})
+
+	t.Run("realtime given up", func(t *testing.T) {
+		// The connection drops and the reconnect fails.
+		srv := newLiveQueryServer(t, &liveQueryServer{
+			list:    func(int32) string { return `{"items":[{"id":"a"}]}` },
+			streams: [][]string{{}},
+			dropAll: true,
+		})
+		c := NewClient(srv.URL, WithRealtimeOptions(RealtimeOptions{
+			Reconnect: ReconnectPolicy{MaxRetries: 1, InitialDelay: 10 * time.Millisecond},
+		}))
+		q, err := NewLiveQuery(context.Background(), c, "posts", nil)
+		if err != nil {
+			t.Fatalf("NewLiveQuery failed: %v", err)
+		}
+		defer q.Close()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for range q.Updates() {
+			}
+		}()
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("Updates was not closed when reconnecting was given up")
+		}
+		if q.Err() == nil {
+			t.Fatal("expected the realtime error")
+		}
+		if got := liveQueryIDs(q.Snapshot()); got != "a" {
+			t.Fatalf("the last snapshot should remain available, got %s", got)
+		}
+	})
+}
+
+func TestRecordComparator(t *testing.T) {
+	rec := func(id string, views float64, title string) *Record {
+		r := &Record{ID: id}
+		r.Set("views", views)
+		r.Set("title", title)
+		return r
+	}
+	a, b, c := rec("a", 1, "z"), rec("b", 2, "y"), rec("c", 2, "x")
+
+	compare := recordComparator("-views,+title")
+	if compare == nil {
+		t.Fatal("expected a comparator for plain fields")
+	}
+	if compare(b, a) >= 0 || compare(c, b) >= 0 || compare(a, a) != 0 {
+		t.Fatal("unexpected order")
+	}
+	for _, sort := range []string{"@random", "author.name", "-@rowid"} {
+		if recordComparator(sort) != nil {
+			t.Errorf("sort %q should not be evaluated locally", sort)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package pocketbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrMissingAuthToken is returned by AuthMiddleware when a request carries no token.
+var ErrMissingAuthToken = &Error{Code: "missing_auth_token"}
+
+const defaultAuthCacheTTL = 30 * time.Second
+
+// rejectedAuthCacheTTL caps how long a rejected token is cached, so a client
+// retrying with a bad token doesn't cause an auth-refresh call per request.
+const rejectedAuthCacheTTL = 5 * time.Second
+
+// maxAuthCacheEntries bounds the token cache; when it is full an arbitrary
+// entry is evicted. Expired entries are dropped when they are looked up.
+const maxAuthCacheEntries = 4096
+
+// AuthMiddlewareOptions configures AuthMiddleware.
+type AuthMiddlewareOptions struct {
+	// Collections restricts accepted tokens to the given auth collections
+	// (names or ids). Tokens of other collections are rejected with 403.
+	// An empty list accepts every auth collection.
+	Collections []string
+
+	// SecretFunc enables local signature verification (HS256) instead of
+	// validating the token with the server's auth-refresh endpoint. It returns
+	// the signing key for the given token claims: PocketBase signs auth tokens
+	// with the record's tokenKey followed by the collection's auth token
+	// secret, so the key depends on the "id" and "collectionId" claims.
+	SecretFunc func(ctx context.Context, claims jwt.MapClaims) ([]byte, error)
+
+	// CacheTTL is how long a validated token and its record are cached.
+	// Defaults to 30 seconds; a negative value disables caching.
+	// Entries never outlive the token's own expiry. Tokens rejected as
+	// invalid (401) are cached too, for at most 5 seconds.
+	CacheTTL time.Duration
+
+	// Optional lets requests without a token through without an auth record.
+	// Requests with an invalid token are still rejected.
+	Optional bool
+
+	// TokenFunc extracts the token from the request.
+	// Defaults to the Authorization header, with or without the "Bearer " prefix.
+	TokenFunc func(r *http.Request) string
+
+	// ErrorHandler writes the response for rejected requests.
+	// Defaults to a JSON body in PocketBase's error format.
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+type (
+	authRecordKey struct{}
+	authTokenKey  struct{}
+)
+
+// AuthRecordFromContext returns the auth record stored by AuthMiddleware.
+func AuthRecordFromContext(ctx context.Context) (*Record, bool) {
+	rec, ok := ctx.Value(authRecordKey{}).(*Record)
+	return rec, ok && rec != nil
+}
+
+// AuthTokenFromContext returns the verified token stored by AuthMiddleware.
+func AuthTokenFromContext(ctx context.Context) (string, bool) {
+	tok, ok := ctx.Value(authTokenKey{}).(string)
+	return tok, ok && tok != ""
+}
+
+// AuthMiddleware returns net/http middleware that verifies PocketBase auth tokens.
+//
+// The token is validated either against the server (auth-refresh, using the
+// token itself) or locally when SecretFunc is configured, in which case the auth
+// record is loaded with the client's own credentials. On success, the record
+// and token are stored in the request context; see AuthRecordFromContext.
+//
+// Rejected requests receive 401 for missing or invalid tokens (matching
+// StatusUnauthorized and ErrInvalidAuthToken or ErrMissingAuthToken) and 403
+// for tokens of collections that are not allowed (matching StatusForbidden).
+func AuthMiddleware(client *Client, opts *AuthMiddlewareOptions) func(http.Handler) http.Handler {
+	v := &authVerifier{client: client, cache: make(map[string]authCacheEntry)}
+	if opts != nil {
+		v.opts = *opts
+	}
+	if v.opts.CacheTTL == 0 {
+		v.opts.CacheTTL = defaultAuthCacheTTL
+	}
+	if v.opts.TokenFunc == nil {
+		v.opts.TokenFunc = tokenFromHeader
+	}
+	if v.opts.ErrorHandler == nil {
+		v.opts.ErrorHandler = writeMiddlewareError
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := v.opts.TokenFunc(r)
+			if token == "" {
+				if v.opts.Optional {
+					next.ServeHTTP(w, r)
+					return
+				}
+				v.opts.ErrorHandler(w, r, &Error{
+					Status:  http.StatusUnauthorized,
+					Code:    ErrMissingAuthToken.Code,
+					Message: "Missing authentication token.",
+				})
+				return
+			}
+
+			rec, err := v.verify(r.Context(), token)
+			if err != nil {
+				v.opts.ErrorHandler(w, r, err)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), authRecordKey{}, rec)
+			ctx = context.WithValue(ctx, authTokenKey{}, token)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// authCacheEntry is a validated token's record, or the error it was rejected with.
+type authCacheEntry struct {
+	record  *Record
+	err     error
+	expires time.Time
+}
+
+type authVerifier struct {
+	client *Client
+	opts   AuthMiddlewareOptions
+
+	mu    sync.Mutex
+	cache map[string]authCacheEntry
+}
+
+func (v *authVerifier) verify(ctx context.Context, token string) (*Record, error) {
+	if entry, ok := v.cached(token); ok {
+		return entry.record, entry.err
+	}
+
+	claims := jwt.MapClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(token, claims); err != nil {
+		return nil, invalidTokenError()
+	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil || !time.Now().Before(exp.Time) {
+		return nil, invalidTokenError()
+	}
+	if t, _ := claims["type"].(string); t != "" && t != "auth" {
+		return nil, invalidTokenError()
+	}
+	recordID, _ := claims["id"].(string)
+	collectionID, _ := claims["collectionId"].(string)
+	if recordID == "" || collectionID == "" {
+		return nil, invalidTokenError()
+	}
+
+	var rec *Record
+	if v.opts.SecretFunc != nil {
+		rec, err = v.verifyLocal(ctx, token, collectionID, recordID)
+	} else {
+		rec, err = v.verifyRemote(ctx, token, collectionID)
+	}
+	if err != nil {
+		// Only invalid tokens are cached; server failures are retried.
+		if GetHTTPStatus(err) == http.StatusUnauthorized {
+			v.store(token, nil, err, exp.Time)
+		}
+		return nil, err
+	}
+
+	if !v.allowed(rec, collectionID) {
+		return nil, forbiddenError()
+	}
+
+	v.store(token, rec, nil, exp.Time)
+	return rec, nil
+}
+
+func (v *authVerifier) verifyLocal(ctx context.Context, token, collectionID, recordID string) (*Record, error) {
+	_, err := jwt.Parse(token, func(t *jwt.Token) (any, error) {
+		claims, _ := t.Claims.(jwt.MapClaims)
+		return v.opts.SecretFunc(ctx, claims)
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, invalidTokenError()
+	}
+
+	rec, err := v.client.Records.GetOne(ctx, collectionID, recordID, nil)
+	if err != nil {
+		if errors.Is(err, StatusNotFound) {
+			return nil, invalidTokenError()
+		}
+		return nil, fmt.Errorf("pocketbase: load auth record: %w", err)
+	}
+	return rec, nil
+}
+
+func (v *authVerifier) verifyRemote(ctx context.Context, token, collectionID string) (*Record, error) {
+	path := fmt.Sprintf("/api/collections/%s/auth-refresh", url.PathEscape(collectionID))
+	var res AuthResponse
+	if err := v.client.send(ContextWithToken(ctx, token), http.MethodPost, path, nil, &res); err != nil {
+		switch GetHTTPStatus(err) {
+		case http.StatusUnauthorized, http.StatusNotFound:
+			return nil, invalidTokenError()
+		case http.StatusForbidden:
+			return nil, forbiddenError()
+		}
+		return nil, fmt.Errorf("pocketbase: validate auth token: %w", err)
+	}
+	if res.Record == nil {
+		return nil, invalidTokenError()
+	}
+	return res.Record, nil
+}
+
+func (v *authVerifier) allowed(rec *Record, collectionID string) bool {
+	if len(v.opts.Collections) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(v.opts.Collections, func(c string) bool {
+		return c == collectionID || c == rec.CollectionID || (rec.CollectionName != "" && c == rec.CollectionName)
+	})
+}
+
+func (v *authVerifier) cached(token string) (authCacheEntry, bool) {
+	if v.opts.CacheTTL < 0 {
+		return authCacheEntry{}, false
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	entry, ok := v.cache[token]
+	if !ok {
+		return authCacheEntry{}, false
+	}
+	if !time.Now().Before(entry.expires) {
+		delete(v.cache, token)
+		return authCacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (v *authVerifier) store(token string, rec *Record, err error, tokenExp time.Time) {
+	if v.opts.CacheTTL < 0 {
+		return
+	}
+	ttl := v.opts.CacheTTL
+	if err != nil && ttl > rejectedAuthCacheTTL {
+		ttl = rejectedAuthCacheTTL
+	}
+	expires := time.Now().Add(ttl)
+	if tokenExp.Before(expires) {
+		expires = tokenExp
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.cache[token]; !ok && len(v.cache) >= maxAuthCacheEntries {
+		for k := range v.cache {
+			delete(v.cache, k)
+			break
+		}
+	}
+	v.cache[token] = authCacheEntry{record: rec, err: err, expires: expires}
+}
+
+func forbiddenError() *Error {
+	return &Error{
+		Status:  http.StatusForbidden,
+		Code:    ErrForbiddenGeneric.Code,
+		Message: "You are not allowed to perform this request.",
+	}
+}
+
+func invalidTokenError() *Error {
+	return &Error{
+		Status:  http.StatusUnauthorized,
+		Code:    ErrInvalidAuthToken.Code,
+		Message: "Missing or invalid authentication token.",
+	}
+}
+
+func tokenFromHeader(r *http.Request) string {
+	h := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(h) > 7 && strings.EqualFold(h[:7], "Bearer ") {
+		return strings.TrimSpace(h[7:])
+	}
+	return h
+}
+
+func writeMiddlewareError(w http.ResponseWriter, r *http.Request, err error) {
+	var pbErr *Error
+	if !errors.As(err, &pbErr) || pbErr.Status == 0 {
+		pbErr = &Error{
+			Status:  http.StatusInternalServerError,
+			Message: "Something went wrong while processing your request.",
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(pbErr.Status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"code":    pbErr.Status,
+		"message": pbErr.Message,
+		"data":    map[string]any{},
+	})
+}
@@ -0,0 +1,213 @@
+package pocketbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newAuthTokenForTest(t *testing.T, secret []byte, recordID, collectionID string, exp time.Time) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"id":           recordID,
+		"collectionId": collectionID,
+		"type":         "auth",
+		"exp":          exp.Unix(),
+	})
+	s, err := token.SignedString(secret)
+	if err != nil {
+		t.Fatalf("Failed to create test token: %v", err)
+	}
+	return s
+}
+
+func newMiddlewareBackend(t *testing.T, validToken string, refreshes *int32) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/collections/col_users/auth-refresh":
+			atomic.AddInt32(refreshes, 1)
+			if r.Header.Get("Authorization") != validToken {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"code":401,"message":"Missing or invalid authentication token."}`))
+				return
+			}
+			fmt.Fprintf(w, `{"token":"refreshed","record":{"id":"user1","collectionId":"col_users","collectionName":"users","email":"a@b.c"}}`)
+		case "/api/collections/col_users/records/user1":
+			fmt.Fprintf(w, `{"id":"user1","collectionId":"col_users","collectionName":"users"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func serveWithMiddleware(mw func(http.Handler) http.Handler, authHeader string) (*httptest.ResponseRecorder, string) {
+	var seen string
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rec, ok := AuthRecordFromContext(r.Context()); ok {
+			seen = rec.ID
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr, seen
+}
+
+func TestAuthMiddlewareRemote(t *testing.T) {
+	token := newAuthTokenForTest(t, []byte("unknown"), "user1", "col_users", time.Now().Add(time.Hour))
+	var refreshes int32
+	backend := newMiddlewareBackend(t, token, &refreshes)
+	defer backend.Close()
+
+	mw := AuthMiddleware(NewClient(backend.URL), nil)
+
+	for i := 0; i < 3; i++ {
+		rr, seen := serveWithMiddleware(mw, "Bearer "+token)
+		if rr.Code != http.StatusOK || seen != "user1" {
+			t.Fatalf("expected authenticated request, got status %d record %q", rr.Code, seen)
+		}
+	}
+	if got := atomic.LoadInt32(&refreshes); got != 1 {
+		t.Fatalf("expected validation result to be cached, got %d auth-refresh calls", got)
+	}
+
+	other := newAuthTokenForTest(t, []byte("unknown"), "user1", "col_users", time.Now().Add(2*time.Hour))
+	for i := 0; i < 3; i++ {
+		if rr, _ := serveWithMiddleware(mw, other); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("expected 401 for rejected token, got %d", rr.Code)
+		}
+	}
+	if got := atomic.LoadInt32(&refreshes); got != 2 {
+		t.Fatalf("expected the rejection to be cached, got %d auth-refresh calls", got)
+	}
+	if rr, _ := serveWithMiddleware(mw, ""); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for missing token, got %d", rr.Code)
+	}
+	if rr, _ := serveWithMiddleware(mw, "not-a-jwt"); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for malformed token, got %d", rr.Code)
+	}
+	expired := newAuthTokenForTest(t, []byte("unknown"), "user1", "col_users", time.Now().Add(-time.Minute))
+	if rr, _ := serveWithMiddleware(mw, expired); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for expired token, got %d", rr.Code)
+	}
+	if got := atomic.LoadInt32(&refreshes); got != 2 {
+		t.Fatalf("malformed and expired tokens must be rejected locally, got %d auth-refresh calls", got)
+	}
+}
+
+func TestAuthMiddlewareCollections(t *testing.T) {
+	token := newAuthTokenForTest(t, []byte("unknown"), "user1", "col_users", time.Now().Add(time.Hour))
+	var refreshes int32
+	backend := newMiddlewareBackend(t, token, &refreshes)
+	defer backend.Close()
+
+	var handled error
+	mw := AuthMiddleware(NewClient(backend.URL), &AuthMiddlewareOptions{
+		Collections: []string{"_superusers"},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			handled = err
+			w.WriteHeader(GetHTTPStatus(err))
+		},
+	})
+	rr, _ := serveWithMiddleware(mw, token)
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rr.Code)
+	}
+	if !errors.Is(handled, StatusForbidden) {
+		t.Fatalf("expected forbidden error, got %v", handled)
+	}
+
+	allowed := AuthMiddleware(NewClient(backend.URL), &AuthMiddlewareOptions{Collections: []string{"users"}})
+	if rr, _ := serveWithMiddleware(allowed, token); rr.Code != http.StatusOK {
+		t.Fatalf("expected collection name to be accepted, got %d", rr.Code)
+	}
+}
+
+func TestAuthMiddlewareLocal(t *testing.T) {
+	secret := []byte("signing-secret")
+	var refreshes int32
+	backend := newMiddlewareBackend(t, "", &refreshes)
+	defer backend.Close()
+
+	mw := AuthMiddleware(NewClient(backend.URL), &AuthMiddlewareOptions{
+		SecretFunc: func(ctx context.Context, claims jwt.MapClaims) ([]byte, error) {
+			if claims["collectionId"] != "col_users" {
+				return nil, errors.New("unknown collection")
+			}
+			return secret, nil
+		},
+	})
+
+	token := newAuthTokenForTest(t, secret, "user1", "col_users", time.Now().Add(time.Hour))
+	rr, seen := serveWithMiddleware(mw, token)
+	if rr.Code != http.StatusOK || seen != "user1" {
+		t.Fatalf("expected authenticated request, got status %d record %q", rr.Code, seen)
+	}
+	if got := atomic.LoadInt32(&refreshes); got != 0 {
+		t.Fatalf("local verification must not call auth-refresh, got %d calls", got)
+	}
+
+	forged := newAuthTokenForTest(t, []byte("wrong"), "user1", "col_users", time.Now().Add(time.Hour))
+	if rr, _ := serveWithMiddleware(mw, forged); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for forged token, got %d", rr.Code)
+	}
+	expired := newAuthTokenForTest(t, secret, "user1", "col_users", time.Now().Add(-time.Minute))
+	if rr, _ := serveWithMiddleware(mw, expired); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for expired token, got %d", rr.Code)
+	}
+}
+
+func TestAuthMiddlewareOptional(t *testing.T) {
+	mw := AuthMiddleware(NewClient("http://127.0.0.1:0"), &AuthMiddlewareOptions{Optional: true})
+	rr, seen := serveWithMiddleware(mw, "")
+	if rr.Code != http.StatusOK || seen != "" {
+		t.Fatalf("expected anonymous request to pass, got status %d record %q", rr.Code, seen)
+	}
+}
+
+func TestAuthMiddlewareForbiddenNotCached(t *testing.T) {
+	var refreshes int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&refreshes, 1)
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"code":403,"message":"The request can be accessed only by guests."}`))
+	}))
+	defer backend.Close()
+
+	mw := AuthMiddleware(NewClient(backend.URL), nil)
+	token := newAuthTokenForTest(t, []byte("unknown"), "user1", "col_users", time.Now().Add(time.Hour))
+	for i := 0; i < 2; i++ {
+		if rr, _ := serveWithMiddleware(mw, token); rr.Code != http.StatusForbidden {
+			t.Fatalf("expected 403, got %d", rr.Code)
+		}
+	}
+	if got := atomic.LoadInt32(&refreshes); got != 2 {
+		t.Fatalf("a forbidden token must not be cached, got %d auth-refresh calls", got)
+	}
+}
+
+func TestAuthVerifierCacheBounded(t *testing.T) {
+	v := &authVerifier{cache: make(map[string]authCacheEntry), opts: AuthMiddlewareOptions{CacheTTL: time.Minute}}
+	exp := time.Now().Add(time.Hour)
+	for i := 0; i < maxAuthCacheEntries+10; i++ {
+		v.store(fmt.Sprintf("token-%d", i), &Record{}, nil, exp)
+	}
+	if len(v.cache) != maxAuthCacheEntries {
+		t.Fatalf("got %d cache entries, want %d", len(v.cache), maxAuthCacheEntries)
+	}
+	if _, ok := v.cached(fmt.Sprintf("token-%d", maxAuthCacheEntries+9)); !ok {
+		t.Fatal("the latest entry should be cached")
+	}
+}
@@ -80,6 +80,11 @@ type ListOptions struct {
 	Fields      string
 	SkipTotal   bool
 	QueryParams map[string]string
+	// Concurrency is the number of pages GetAll and the Iterate methods fetch
+	// in parallel. Values above 1 request the first page with totals and the
+	// remaining pages concurrently; items are still returned in order.
+	// GetList ignores it.
+	Concurrency int
 }
 
 // GetOneOptions contains options for retrieving a single record.
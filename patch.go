@@ -0,0 +1,97 @@
+package pocketbase
+
+import (
+	"context"
+	"maps"
+)
+
+// Patch builds a partial record update using PocketBase's field modifiers:
+//
+//	patch := pocketbase.NewPatch().
+//		Set("title", "Hello").
+//		Append("tags", "go", "sdk").   // "tags+": ["go", "sdk"]
+//		Remove("editors", userID).     // "editors-": [userID]
+//		Increment("views", 1)          // "views+": 1
+//	rec, err := client.Records.Update(ctx, "posts", id, patch)
+//
+// Patch implements Mappable, so it can be passed as the body of Update and
+// UpdateWithOptions, and its ToMap result to NewUpdateRequest. The zero value
+// is an empty patch.
+type Patch struct {
+	data map[string]any
+}
+
+var _ Mappable = (*Patch)(nil)
+
+// NewPatch returns an empty patch.
+func NewPatch() *Patch {
+	return &Patch{}
+}
+
+// Set replaces the value of field.
+func (p *Patch) Set(field string, value any) *Patch {
+	p.put(field, value)
+	return p
+}
+
+// Append adds values to the end of a multi-value field (relation, select
+// or file), as "field+".
+func (p *Patch) Append(field string, values ...any) *Patch {
+	p.appendValues(field+"+", values)
+	return p
+}
+
+// Prepend adds values to the start of a multi-value field, as "+field".
+func (p *Patch) Prepend(field string, values ...any) *Patch {
+	p.appendValues("+"+field, values)
+	return p
+}
+
+// Remove removes values from a multi-value field, as "field-".
+func (p *Patch) Remove(field string, values ...any) *Patch {
+	p.appendValues(field+"-", values)
+	return p
+}
+
+// Increment adds n to a number field, as "field+".
+func (p *Patch) Increment(field string, n float64) *Patch {
+	p.put(field+"+", n)
+	return p
+}
+
+// Decrement subtracts n from a number field, as "field-".
+func (p *Patch) Decrement(field string, n float64) *Patch {
+	p.put(field+"-", n)
+	return p
+}
+
+// ToMap returns the request body with the modifier keys.
+func (p *Patch) ToMap() map[string]any {
+	if p.data == nil {
+		return map[string]any{}
+	}
+	return maps.Clone(p.data)
+}
+
+func (p *Patch) put(key string, value any) {
+	if p.data == nil {
+		p.data = make(map[string]any)
+	}
+	p.data[key] = value
+}
+
+// appendValues adds values to the list under key, so repeated calls combine.
+func (p *Patch) appendValues(key string, values []any) {
+	list, _ := p.data[key].([]any)
+	p.put(key, append(list, values...))
+}
+
+// ApplyPatch updates a record with patch, such as a *Patch or a generated
+// model patch, and returns the result converted to T.
+func (s *TypedRecordService[T]) ApplyPatch(ctx context.Context, recordID string, patch Mappable) (*T, error) {
+	rec, err := s.RecordService.Update(ctx, s.Collection, recordID, patch)
+	if err != nil {
+		return nil, err
+	}
+	return convertRecord[T](rec)
+}
@@ -0,0 +1,79 @@
+package pocketbase
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/goccy/go-json"
+)
+
+func TestPatchToMap(t *testing.T) {
+	p := NewPatch().
+		Set("title", "Hello").
+		Append("tags", "go").
+		Append("tags", "sdk").
+		Prepend("editors", "u1").
+		Remove("viewers", "u2", "u3").
+		Increment("views", 1).
+		Decrement("stock", 2)
+
+	want := map[string]any{
+		"title":    "Hello",
+		"tags+":    []any{"go", "sdk"},
+		"+editors": []any{"u1"},
+		"viewers-": []any{"u2", "u3"},
+		"views+":   float64(1),
+		"stock-":   float64(2),
+	}
+	if got := p.ToMap(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// ToMap returns a copy.
+	p.ToMap()["title"] = "changed"
+	if p.ToMap()["title"] != "Hello" {
+		t.Fatal("ToMap exposed the patch's internal map")
+	}
+
+	var empty Patch
+	if m := empty.ToMap(); m == nil || len(m) != 0 {
+		t.Fatalf("expected an empty map, got %v", m)
+	}
+}
+
+func TestPatchUpdate(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Path != "/api/collections/posts/records/p1" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": "p1", "title": "Hello"})
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL)
+
+	patch := NewPatch().Set("title", "Hello").Append("tags", "go").Increment("views", 1)
+	post, err := NewTypedRecordService[testPost](c, "posts").ApplyPatch(context.Background(), "p1", patch)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if post.Title != "Hello" {
+		t.Fatalf("unexpected record: %+v", post)
+	}
+	want := map[string]any{"title": "Hello", "tags+": []any{"go"}, "views+": float64(1)}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got body %v, want %v", got, want)
+	}
+
+	req, err := c.Records.NewUpdateRequest("posts", "p1", patch.ToMap())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(req.Body, patch.ToMap()) {
+		t.Fatalf("unexpected batch body: %v", req.Body)
+	}
+}
@@ -0,0 +1,182 @@
+package pocketbase
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The builders in this file produce the comma separated strings accepted by
+// ListOptions, GetOneOptions and WriteOptions, e.g.
+//
+//	opts := &pocketbase.ListOptions{
+//		Sort:   pocketbase.Sort("-created", "title").String(),
+//		Expand: pocketbase.Expand("author.profile", "tags").String(),
+//		Fields: pocketbase.Fields("id", "title").Excerpt("content", 200, true).String(),
+//	}
+//
+// Entries are validated and de-duplicated. Invalid entries are kept in the
+// output, so the server reports them, and Err returns the problems found.
+
+// maxExpandDepth is the nesting limit of expand paths enforced by the server.
+const maxExpandDepth = 6
+
+var (
+	sortKeyPattern    = regexp.MustCompile(`^[+-]?(@random|@rowid|[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*)$`)
+	expandPathPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+	fieldPattern      = regexp.MustCompile(`^(\*|[A-Za-z_@][A-Za-z0-9_@]*(\.(\*|[A-Za-z_@][A-Za-z0-9_@]*))*)(:excerpt\(\d+(,\s*(true|false))?\))?$`)
+)
+
+// SortExpr is a sort expression built with Sort.
+type SortExpr struct {
+	keys []string
+	err  error
+}
+
+// Sort builds a sort expression from keys such as "created" (ascending),
+// "-created" (descending) or "@random". A field listed twice keeps its
+// first direction.
+func Sort(keys ...string) SortExpr {
+	return SortExpr{}.add(keys...)
+}
+
+// Asc appends ascending fields.
+func (s SortExpr) Asc(fields ...string) SortExpr {
+	return s.add(fields...)
+}
+
+// Desc appends descending fields.
+func (s SortExpr) Desc(fields ...string) SortExpr {
+	keys := make([]string, len(fields))
+	for i, f := range fields {
+		keys[i] = "-" + f
+	}
+	return s.add(keys...)
+}
+
+// Random appends @random.
+func (s SortExpr) Random() SortExpr {
+	return s.add("@random")
+}
+
+func (s SortExpr) add(keys ...string) SortExpr {
+	out := SortExpr{keys: slices.Clone(s.keys), err: s.err}
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if !sortKeyPattern.MatchString(key) {
+			out.err = errors.Join(out.err, fmt.Errorf("pocketbase: invalid sort key %q", key))
+		}
+		field := strings.TrimLeft(key, "+-")
+		if slices.ContainsFunc(out.keys, func(k string) bool { return strings.TrimLeft(k, "+-") == field }) {
+			continue
+		}
+		out.keys = append(out.keys, key)
+	}
+	return out
+}
+
+// String returns the expression for ListOptions.Sort.
+func (s SortExpr) String() string { return strings.Join(s.keys, ",") }
+
+// Err returns the validation errors, if any.
+func (s SortExpr) Err() error { return s.err }
+
+// Build returns the expression and the validation errors, if any.
+func (s SortExpr) Build() (string, error) { return s.String(), s.err }
+
+// ExpandExpr is a list of relations to expand, built with Expand.
+type ExpandExpr struct {
+	paths []string
+	err   error
+}
+
+// Expand builds an expand expression from relation paths such as "author"
+// or "author.profile" (nested up to 6 levels). Paths already covered by a
+// longer path are dropped, as expanding "author.profile" expands "author" too.
+func Expand(paths ...string) ExpandExpr {
+	return ExpandExpr{}.Add(paths...)
+}
+
+// Add appends relation paths.
+func (e ExpandExpr) Add(paths ...string) ExpandExpr {
+	out := ExpandExpr{paths: slices.Clone(e.paths), err: e.err}
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if !expandPathPattern.MatchString(path) {
+			out.err = errors.Join(out.err, fmt.Errorf("pocketbase: invalid expand path %q", path))
+		} else if strings.Count(path, ".")+1 > maxExpandDepth {
+			out.err = errors.Join(out.err, fmt.Errorf("pocketbase: expand path %q is nested deeper than %d levels", path, maxExpandDepth))
+		}
+		if slices.ContainsFunc(out.paths, func(p string) bool { return p == path || strings.HasPrefix(p, path+".") }) {
+			continue
+		}
+		out.paths = slices.DeleteFunc(out.paths, func(p string) bool { return strings.HasPrefix(path, p+".") })
+		out.paths = append(out.paths, path)
+	}
+	return out
+}
+
+// Nested appends relation paths below parent, e.g. Nested("author", "profile", "company").
+func (e ExpandExpr) Nested(parent string, children ...string) ExpandExpr {
+	paths := make([]string, len(children))
+	for i, c := range children {
+		paths[i] = parent + "." + c
+	}
+	return e.Add(paths...)
+}
+
+// String returns the expression for the Expand options.
+func (e ExpandExpr) String() string { return strings.Join(e.paths, ",") }
+
+// Err returns the validation errors, if any.
+func (e ExpandExpr) Err() error { return e.err }
+
+// Build returns the expression and the validation errors, if any.
+func (e ExpandExpr) Build() (string, error) { return e.String(), e.err }
+
+// FieldsExpr is a field selection, built with Fields.
+type FieldsExpr struct {
+	fields []string
+	err    error
+}
+
+// Fields builds a field selection from names such as "id", "*",
+// "expand.author.name" or "content:excerpt(200,true)".
+func Fields(fields ...string) FieldsExpr {
+	return FieldsExpr{}.Add(fields...)
+}
+
+// Add appends fields.
+func (f FieldsExpr) Add(fields ...string) FieldsExpr {
+	out := FieldsExpr{fields: slices.Clone(f.fields), err: f.err}
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if !fieldPattern.MatchString(field) {
+			out.err = errors.Join(out.err, fmt.Errorf("pocketbase: invalid field %q", field))
+		}
+		name, _, _ := strings.Cut(field, ":")
+		if slices.ContainsFunc(out.fields, func(s string) bool { n, _, _ := strings.Cut(s, ":"); return n == name }) {
+			continue
+		}
+		out.fields = append(out.fields, field)
+	}
+	return out
+}
+
+// Excerpt appends field with the :excerpt modifier, which returns at most
+// maxLength characters of its plain text, optionally ending with "...".
+func (f FieldsExpr) Excerpt(field string, maxLength int, withEllipsis bool) FieldsExpr {
+	return f.Add(field + ":excerpt(" + strconv.Itoa(maxLength) + "," + strconv.FormatBool(withEllipsis) + ")")
+}
+
+// String returns the expression for the Fields options.
+func (f FieldsExpr) String() string { return strings.Join(f.fields, ",") }
+
+// Err returns the validation errors, if any.
+func (f FieldsExpr) Err() error { return f.err }
+
+// Build returns the expression and the validation errors, if any.
+func (f FieldsExpr) Build() (string, error) { return f.String(), f.err }
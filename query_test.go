@@ -0,0 +1,60 @@
+package pocketbase
+
+import "testing"
+
+func TestSortBuilder(t *testing.T) {
+	got, err := Sort("-created", "title").Asc("views").Desc("title", "rank").Random().Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "-created,title,views,-rank,@random"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	s := Sort("title", "bad key", "-")
+	if s.Err() == nil {
+		t.Error("expected an error for invalid sort keys")
+	}
+	if want := "title,bad key,-"; s.String() != want {
+		t.Errorf("got %q, want %q", s.String(), want)
+	}
+}
+
+func TestExpandBuilder(t *testing.T) {
+	got, err := Expand("author", "tags", "author.profile").Nested("author.profile", "company").Add("tags").Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "tags,author.profile.company"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if err := Expand("a.b.c.d.e.f").Err(); err != nil {
+		t.Errorf("6 levels should be allowed: %v", err)
+	}
+	if err := Expand("a.b.c.d.e.f.g").Err(); err == nil {
+		t.Error("expected an error for 7 levels")
+	}
+	if err := Expand("author..profile").Err(); err == nil {
+		t.Error("expected an error for an empty path segment")
+	}
+}
+
+func TestFieldsBuilder(t *testing.T) {
+	got, err := Fields("id", "*", "expand.author.name", "id").Excerpt("content", 200, true).Add("content").Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "id,*,expand.author.name,content:excerpt(200,true)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if err := Fields("expand.*", "title:excerpt(10)").Err(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, field := range []string{"", "title:upper", "title:excerpt(x)", "a,b"} {
+		if err := Fields(field).Err(); err == nil {
+			t.Errorf("expected an error for %q", field)
+		}
+	}
+}
@@ -4,12 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"net/http"
 	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/goccy/go-json"
-	"github.com/tmaxmax/go-sse"
 )
 
 // RealtimeServiceAPI defines the real-time subscription functionality.
@@ -23,103 +25,444 @@ type RealtimeCallback func(event *RealtimeEvent, err error)
 // UnsubscribeFunc is a function that unsubscribes from a real-time topic.
 type UnsubscribeFunc func()
 
+// RealtimeOptions configures the realtime connections of a client.
+type RealtimeOptions struct {
+	// Reconnect controls reconnection after the SSE stream ends unexpectedly.
+	Reconnect ReconnectPolicy
+
+	// ConnectTimeout bounds the wait for PB_CONNECT after opening a stream.
+	// Defaults to 30s.
+	ConnectTimeout time.Duration
+	// IdleTimeout treats a stream that delivered no event for this long as
+	// dead and reconnects. Zero disables idle detection.
+	IdleTimeout time.Duration
+
+	// OnStateChange, if set, is called whenever a connection changes state.
+	// It runs on the connection's goroutine and must not block.
+	OnStateChange func(RealtimeStatus)
+}
+
+// RealtimeState is the state of a realtime connection.
+type RealtimeState int
+
+const (
+	// RealtimeDisconnected means there is no connection, e.g. before the first subscription.
+	RealtimeDisconnected RealtimeState = iota
+	// RealtimeConnecting means the first stream is being opened.
+	RealtimeConnecting
+	// RealtimeConnected means the stream is open and the topics are submitted.
+	RealtimeConnected
+	// RealtimeReconnecting means the stream was lost and is being re-established.
+	RealtimeReconnecting
+	// RealtimeClosed means the connection was closed or reconnecting was given up.
+	RealtimeClosed
+)
+
+func (s RealtimeState) String() string {
+	switch s {
+	case RealtimeDisconnected:
+		return "disconnected"
+	case RealtimeConnecting:
+		return "connecting"
+	case RealtimeConnected:
+		return "connected"
+	case RealtimeReconnecting:
+		return "reconnecting"
+	case RealtimeClosed:
+		return "closed"
+	}
+	return fmt.Sprintf("RealtimeState(%d)", int(s))
+}
+
+// RealtimeStatus describes a realtime connection.
+type RealtimeStatus struct {
+	State RealtimeState
+	// ClientID is the id assigned by the server to the current stream.
+	ClientID string
+	// LastEvent is when the last event was received.
+	LastEvent time.Time
+	// Reconnects counts how often the connection was re-established.
+	Reconnects int
+	// Err is the reason of the last disconnect, if any.
+	Err error
+}
+
+// ReconnectPolicy controls how realtime subscriptions reconnect after the SSE
+// stream ends (server restart, proxy idle timeout, network failure).
+// Delays grow exponentially and are randomized so that many clients don't
+// reconnect at the same moment.
+type ReconnectPolicy struct {
+	// MaxRetries is the number of consecutive failed attempts before the
+	// subscription gives up and reports an error to the callback.
+	// Zero means unlimited; a negative value disables reconnecting.
+	MaxRetries int
+	// InitialDelay is the wait before the first attempt. Defaults to 500ms.
+	InitialDelay time.Duration
+	// MaxDelay caps the wait between attempts. Defaults to 30s.
+	MaxDelay time.Duration
+	// Multiplier grows the delay after each failed attempt. Defaults to 2.
+	Multiplier float64
+	// Jitter randomizes each delay by up to ±Jitter of its value.
+	// Must be in (0, 1); defaults to 0.5. A negative value disables jitter.
+	Jitter float64
+}
+
+// WithRealtimeOptions configures the realtime connections of the client.
+func WithRealtimeOptions(opts RealtimeOptions) ClientOption {
+	return func(c *Client) {
+		c.realtimeOpts = opts
+	}
+}
+
 // RealtimeService handles the real-time subscription API.
+//
+// All subscriptions share a single SSE connection per client (and per auth
+// override, see ContextWithAuth), like the JS SDK. The connection is opened
+// by the first subscription and closed when the last one unsubscribes.
 type RealtimeService struct {
-	Client *Client
+	Client  *Client
+	Options RealtimeOptions
+
+	mu    sync.Mutex
+	conns map[AuthStrategy]*realtimeConn
+	// failed holds the final status of connections that gave up or were
+	// shut down with an error, until a new connection replaces them.
+	failed map[AuthStrategy]RealtimeStatus
 }
 
 var _ RealtimeServiceAPI = (*RealtimeService)(nil)
 
 // Subscribe subscribes to specific topics and executes a callback when an event occurs.
 // This implementation ensures that the subscription is confirmed before returning.
+//
+// If the connection is lost afterwards, it is re-established according to
+// Options.Reconnect and the topics are submitted again with the new client id.
+// The returned UnsubscribeFunc stays valid across reconnects. The callback
+// receives an error only when reconnecting is given up.
+//
+// The subscription ends when the returned UnsubscribeFunc is called or ctx is done.
 func (s *RealtimeService) Subscribe(ctx context.Context, topics []string, callback RealtimeCallback) (UnsubscribeFunc, error) {
-	path := "/api/realtime"
-	endpoint, err := url.JoinPath(s.Client.BaseURL, path)
-	if err != nil {
-		return nil, fmt.Errorf("pocketbase: invalid realtime path: %w", err)
+	return s.subscribe(ctx, newRealtimeEventListener(topics, callback))
+}
+
+// newRealtimeEventListener returns a listener that decodes events for callback.
+func newRealtimeEventListener(topics []string, callback RealtimeCallback) *realtimeListener {
+	return &realtimeListener{
+		topics: topics,
+		onEvent: func(_ string, data []byte) {
+			var rtEvent RealtimeEvent
+			if err := json.Unmarshal(data, &rtEvent); err != nil {
+				callback(nil, fmt.Errorf("pocketbase: failed to unmarshal realtime event: %w. Raw data: %s", err, string(data)))
+				return
+			}
+			callback(&rtEvent, nil)
+		},
+		onError: func(err error) {
+			callback(nil, err)
+		},
 	}
+}
 
-	subCtx, cancel := context.WithCancel(ctx)
-	req, err := http.NewRequestWithContext(subCtx, http.MethodGet, endpoint, nil)
-	if err != nil {
-		cancel()
-		return nil, fmt.Errorf("pocketbase: failed to create sse request: %w", err)
+// RealtimeMessage is a raw realtime message, such as a custom message
+// broadcast by the server application on an arbitrary topic.
+type RealtimeMessage struct {
+	// Topic is the SSE event name, which is the subscription topic.
+	Topic string
+	// Data is the message payload.
+	Data json.RawMessage
+}
+
+// RealtimeMessageCallback handles raw realtime messages.
+type RealtimeMessageCallback func(msg *RealtimeMessage, err error)
+
+// SubscribeRaw is like Subscribe but delivers messages without decoding
+// them as record events. Use it for custom topics; DecodeMessage decodes the
+// payload into a type of your choice.
+func (s *RealtimeService) SubscribeRaw(ctx context.Context, topics []string, callback RealtimeMessageCallback) (UnsubscribeFunc, error) {
+	l := &realtimeListener{
+		topics: topics,
+		onEvent: func(name string, data []byte) {
+			callback(&RealtimeMessage{Topic: name, Data: slices.Clone(data)}, nil)
+		},
+		onError: func(err error) {
+			callback(nil, err)
+		},
 	}
+	return s.subscribe(ctx, l)
+}
 
-	sseHTTPClient := *s.Client.HTTPClient
-	sseHTTPClient.Timeout = 0 // Disable timeout for streaming
+// DecodeMessage decodes the payload of msg into a new T.
+func DecodeMessage[T any](msg *RealtimeMessage) (*T, error) {
+	if msg == nil {
+		return nil, fmt.Errorf("pocketbase: nil realtime message")
+	}
+	var v T
+	if err := json.Unmarshal(msg.Data, &v); err != nil {
+		return nil, fmt.Errorf("pocketbase: failed to decode realtime message on %q: %w", msg.Topic, err)
+	}
+	return &v, nil
+}
 
-	sseClient := sse.Client{HTTPClient: &sseHTTPClient}
-	conn := sseClient.NewConnection(req)
+// SubscribeOptions are per-topic options forwarded to the server, which applies
+// them to the records it sends for the subscription, e.g. to expand relations.
+type SubscribeOptions struct {
+	// Filter limits the events to records matching the expression.
+	Filter string
+	// Expand lists the relations to expand in the delivered records,
+	// e.g. "author,comments.user". It is encoded into the topic's options query.
+	Expand string
+	// Fields restricts the fields of the delivered records, e.g. "id,title".
+	// It is encoded into the topic's options query.
+	Fields string
+	// Query holds additional query parameters.
+	Query map[string]string
+	// Headers holds additional request headers.
+	Headers map[string]string
 
-	connectErrChan := make(chan error, 1)
+	// CatchUp replays the changes missed while the connection was down.
+	// After each reconnect the records of "collection/*" and
+	// "collection/recordID" topics updated since the last event are listed
+	// and delivered as create or update events, followed by a
+	// *RealtimeGapError, because deletes can't be recovered. It relies on the
+	// collection's "created" and "updated" fields, which are added to a
+	// restricted Fields list, and is not sent to the server.
+	CatchUp bool
+}
 
-	// Register event handler
-	conn.SubscribeToAll(func(event sse.Event) {
-		// --- Initial Connection Handling ---
-		if event.Type == "PB_CONNECT" {
-			var connectEvent struct {
-				ClientID string `json:"clientId"`
-			}
-			if err := json.Unmarshal([]byte(event.Data), &connectEvent); err != nil {
-				connectErrChan <- fmt.Errorf("pocketbase: failed to unmarshal PB_CONNECT event: %w", err)
-				return
-			}
-			if connectEvent.ClientID == "" {
-				connectErrChan <- fmt.Errorf("pocketbase: PB_CONNECT event missing clientId")
-				return
-			}
+// SubscribeWithOptions is like Subscribe but applies opts to every topic.
+// With opts.CatchUp the callback also receives a *RealtimeGapError after
+// each reconnect; it is informational and the subscription stays active.
+func (s *RealtimeService) SubscribeWithOptions(ctx context.Context, topics []string, opts *SubscribeOptions, callback RealtimeCallback) (UnsubscribeFunc, error) {
+	l, err := s.newOptionsListener(topics, opts, callback)
+	if err != nil {
+		return nil, err
+	}
+	return s.subscribe(ctx, l)
+}
 
-			// Send subscription request using the main client's send method
-			body := map[string]any{"clientId": connectEvent.ClientID, "subscriptions": topics}
-			if err := s.Client.send(subCtx, http.MethodPost, path, body, nil); err != nil {
-				connectErrChan <- fmt.Errorf("pocketbase: failed to send subscription request: %w", err)
-			} else {
-				connectErrChan <- nil // Success
-			}
-			return
-		}
+// realtimeOptionsSubscriber is implemented by realtime services that support
+// SubscribeOptions, such as *RealtimeService.
+type realtimeOptionsSubscriber interface {
+	SubscribeWithOptions(ctx context.Context, topics []string, opts *SubscribeOptions, callback RealtimeCallback) (UnsubscribeFunc, error)
+}
 
-		// --- Regular Event Handling ---
-		if len(event.Data) == 0 { // Ignore empty data (e.g., keep-alive messages)
-			return
-		}
+// subscribeWithOptions subscribes through rt, which must support opts
+// unless opts is nil.
+func subscribeWithOptions(ctx context.Context, rt RealtimeServiceAPI, topics []string, opts *SubscribeOptions, callback RealtimeCallback) (UnsubscribeFunc, error) {
+	if s, ok := rt.(realtimeOptionsSubscriber); ok {
+		return s.SubscribeWithOptions(ctx, topics, opts, callback)
+	}
+	if opts == nil {
+		return rt.Subscribe(ctx, topics, callback)
+	}
+	return nil, fmt.Errorf("pocketbase: realtime service %T does not support subscription options", rt)
+}
 
-		var rtEvent RealtimeEvent
-		if err := json.Unmarshal([]byte(event.Data), &rtEvent); err != nil {
-			callback(nil, fmt.Errorf("pocketbase: failed to unmarshal realtime event: %w. Raw data: %s", err, string(event.Data)))
-			return
+// newOptionsListener returns a listener that decodes events for callback on
+// the topics encoded with opts, catching up after reconnects if requested.
+func (s *RealtimeService) newOptionsListener(topics []string, opts *SubscribeOptions, callback RealtimeCallback) (*realtimeListener, error) {
+	opts = opts.withCatchUpFields()
+	encoded, err := opts.encodeTopics(topics)
+	if err != nil {
+		return nil, err
+	}
+	l := newRealtimeEventListener(encoded, callback)
+	if opts != nil && opts.CatchUp {
+		newRealtimeCatchUp(s.Client, topics, opts).attach(l, func(err error) {
+			callback(nil, err)
+		})
+	}
+	return l, nil
+}
+
+// encodeTopics applies encodeTopic to every topic.
+func (o *SubscribeOptions) encodeTopics(topics []string) ([]string, error) {
+	encoded := make([]string, len(topics))
+	for i, topic := range topics {
+		t, err := o.encodeTopic(topic)
+		if err != nil {
+			return nil, err
 		}
-		callback(&rtEvent, nil)
-	})
+		encoded[i] = t
+	}
+	return encoded, nil
+}
 
-	// Start connection in a separate goroutine.
-	go func() {
-		// Connect() blocks until the connection is closed.
-		if err := conn.Connect(); err != nil && !errors.Is(err, context.Canceled) {
-			callback(nil, fmt.Errorf("pocketbase: sse subscription failed: %w", err))
+// encodeTopic appends the options to topic the way the server expects:
+// topic?options={"query":{...},"headers":{...}}. The server echoes the full
+// topic as the event name, so the encoding must be deterministic.
+func (o *SubscribeOptions) encodeTopic(topic string) (string, error) {
+	if o == nil {
+		return topic, nil
+	}
+	q := url.Values{}
+	applyListOptions(q, &ListOptions{Filter: o.Filter, Expand: o.Expand, Fields: o.Fields, QueryParams: o.Query})
+	if len(q) == 0 && len(o.Headers) == 0 {
+		return topic, nil
+	}
+
+	var options struct {
+		Query   map[string]string `json:"query,omitempty"`
+		Headers map[string]string `json:"headers,omitempty"`
+	}
+	if len(q) > 0 {
+		options.Query = make(map[string]string, len(q))
+		for k := range q {
+			options.Query[k] = q.Get(k)
 		}
-	}()
+	}
+	options.Headers = o.Headers
 
-	// Wait for the subscription to be confirmed or fail
-	select {
-	case err := <-connectErrChan:
+	data, err := json.Marshal(options)
+	if err != nil {
+		return "", fmt.Errorf("pocketbase: failed to encode subscription options: %w", err)
+	}
+	sep := "?"
+	if strings.Contains(topic, "?") {
+		sep = "&"
+	}
+	return topic + sep + "options=" + url.QueryEscape(string(data)), nil
+}
+
+// subscribe registers l on the shared connection for ctx's auth.
+func (s *RealtimeService) subscribe(ctx context.Context, l *realtimeListener) (UnsubscribeFunc, error) {
+	timeout := s.Options.ConnectTimeout
+	if timeout <= 0 {
+		timeout = defaultConnectTimeout
+	}
+	for {
+		conn := s.conn(ctx)
+		unsubscribe, err := conn.add(ctx, l, timeout)
+		if errors.Is(err, errRealtimeConnClosed) {
+			continue // the connection was closing; open a new one
+		}
 		if err != nil {
-			cancel() // Clean up context on failure
 			return nil, err
 		}
-	case <-ctx.Done():
-		cancel() // Clean up context on failure
-		return nil, ctx.Err()
-	case <-time.After(30 * time.Second):
-		cancel() // Clean up context on timeout
-		return nil, fmt.Errorf("pocketbase: subscribe timeout waiting for PB_CONNECT")
+		stop := context.AfterFunc(ctx, unsubscribe)
+		return func() {
+			stop()
+			unsubscribe()
+		}, nil
+	}
+}
+
+// conn returns the shared connection for ctx's auth, creating it if needed.
+// Auth overrides that can't be used as map keys get a dedicated connection.
+func (s *RealtimeService) conn(ctx context.Context) *realtimeConn {
+	key, _ := authFromContext(ctx)
+	if key != nil && !reflect.TypeOf(key).Comparable() {
+		return newRealtimeConn(s, key)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conns == nil {
+		s.conns = make(map[AuthStrategy]*realtimeConn)
+	}
+	conn, ok := s.conns[key]
+	if !ok {
+		conn = newRealtimeConn(s, key)
+		s.conns[key] = conn
+		delete(s.failed, key)
+	}
+	return conn
+}
+
+// Status returns the status of the shared connection for ctx's auth.
+// After reconnecting was given up or the auth was cleared, it reports
+// RealtimeClosed and the error until a new subscription opens a connection.
+func (s *RealtimeService) Status(ctx context.Context) RealtimeStatus {
+	key, _ := authFromContext(ctx)
+	if key != nil && !reflect.TypeOf(key).Comparable() {
+		return RealtimeStatus{}
+	}
+	s.mu.Lock()
+	conn := s.conns[key]
+	failed := s.failed[key]
+	s.mu.Unlock()
+	if conn == nil {
+		return failed
+	}
+	return conn.status()
+}
+
+// drop forgets conn once it is closing. If it closes with err, its final
+// status is kept for Status.
+func (s *RealtimeService) drop(conn *realtimeConn, err error) {
+	if conn.key != nil && !reflect.TypeOf(conn.key).Comparable() {
+		return
+	}
+	status := conn.status()
+	status.State = RealtimeClosed
+	status.Err = err
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conns[conn.key] != conn {
+		return
+	}
+	delete(s.conns, conn.key)
+	if err != nil {
+		if s.failed == nil {
+			s.failed = make(map[AuthStrategy]RealtimeStatus)
+		}
+		s.failed[conn.key] = status
 	}
+}
+
+// RealtimeAction is the kind of change reported by a record realtime event.
+type RealtimeAction string
 
-	// Unsubscribe function to be returned to the caller
-	unsubscribe := func() {
-		cancel()
+// Record realtime actions.
+const (
+	RealtimeActionCreate RealtimeAction = "create"
+	RealtimeActionUpdate RealtimeAction = "update"
+	RealtimeActionDelete RealtimeAction = "delete"
+)
+
+// TypedRealtimeEvent is a realtime event whose record is decoded into T.
+type TypedRealtimeEvent[T any] struct {
+	Action RealtimeAction
+	Record *T
+}
+
+// TypedRealtimeCallback handles typed realtime events.
+type TypedRealtimeCallback[T any] func(event TypedRealtimeEvent[T], err error)
+
+// Subscribe subscribes to a topic of the service's collection and decodes
+// every event into T. topic is "*" (or empty) for all records of the
+// collection, or a record id.
+func (s *TypedRecordService[T]) Subscribe(ctx context.Context, topic string, callback TypedRealtimeCallback[T]) (UnsubscribeFunc, error) {
+	return s.SubscribeWithOptions(ctx, topic, nil, callback)
+}
+
+// SubscribeWithOptions is like Subscribe but applies opts to the topic.
+func (s *TypedRecordService[T]) SubscribeWithOptions(ctx context.Context, topic string, opts *SubscribeOptions, callback TypedRealtimeCallback[T]) (UnsubscribeFunc, error) {
+	if topic == "" {
+		topic = "*"
 	}
+	return subscribeWithOptions(ctx, s.Client.Realtime, []string{s.Collection + "/" + topic}, opts, func(event *RealtimeEvent, err error) {
+		if err != nil {
+			callback(TypedRealtimeEvent[T]{}, err)
+			return
+		}
+		typed := TypedRealtimeEvent[T]{Action: RealtimeAction(event.Action)}
+		if event.Record != nil {
+			item, err := convertRecord[T](event.Record)
+			if err != nil {
+				callback(typed, err)
+				return
+			}
+			typed.Record = item
+		}
+		callback(typed, nil)
+	})
+}
 
-	return unsubscribe, nil
+// SubscribeRecord subscribes to the changes of a single record.
+func (s *TypedRecordService[T]) SubscribeRecord(ctx context.Context, recordID string, callback TypedRealtimeCallback[T]) (UnsubscribeFunc, error) {
+	if recordID == "" {
+		return nil, fmt.Errorf("pocketbase: record ID is required")
+	}
+	return s.Subscribe(ctx, recordID, callback)
 }
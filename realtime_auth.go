@@ -0,0 +1,105 @@
+package pocketbase
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrRealtimeAuthCleared is reported to realtime subscriptions that were
+// closed because the client's auth was cleared.
+var ErrRealtimeAuthCleared = errors.New("pocketbase: realtime subscription closed: auth cleared")
+
+// authChanged follows a change of the client's auth on the connection that
+// uses it. Connections with an auth override from the context are not affected.
+func (s *RealtimeService) authChanged(cleared bool) {
+	s.mu.Lock()
+	conn := s.conns[nil]
+	s.mu.Unlock()
+	if conn == nil {
+		return
+	}
+	if cleared {
+		conn.shutdown(ErrRealtimeAuthCleared)
+		return
+	}
+	go conn.reauth()
+}
+
+// shutdown closes the connection and reports err to its listeners.
+func (c *realtimeConn) shutdown(err error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	c.closed = true
+	c.closeErr = err
+	c.mu.Unlock()
+
+	c.service.drop(c, err)
+	c.cancel()
+}
+
+// shutdownErr returns the error passed to shutdown, if any.
+func (c *realtimeConn) shutdownErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closeErr
+}
+
+// reauth brings the server's view of the connection's auth up to date.
+// The server only lets a client id gain auth, not change it, so a different
+// identity requires a new stream; the same identity with a new token is
+// submitted again.
+func (c *realtimeConn) reauth() {
+	token, err := c.client.currentToken(c.ctx)
+	if err != nil {
+		return // the next request reports the error
+	}
+
+	c.submitMu.Lock()
+	defer c.submitMu.Unlock()
+	if token == c.submittedToken {
+		return
+	}
+	if old := tokenIdentity(c.submittedToken); old != "" && old != tokenIdentity(token) {
+		c.mu.Lock()
+		cancel := c.streamCancel
+		c.mu.Unlock()
+		if cancel != nil {
+			c.restart.Store(true)
+			cancel()
+		}
+		return
+	}
+	c.submitted = ""
+	_ = c.submitLocked(c.ctx)
+}
+
+// scheduleAuthRefresh arranges for the subscriptions to be submitted again
+// shortly before token expires, once strategies consider it due for refresh.
+// c.submitMu must be held.
+func (c *realtimeConn) scheduleAuthRefresh(token string) {
+	if c.authTimer != nil {
+		c.authTimer.Stop()
+		c.authTimer = nil
+	}
+	exp := tokenExpiry(token)
+	if exp.IsZero() {
+		return
+	}
+	d := time.Until(exp.Add(-tokenExpiryLeeway / 2))
+	if d <= 0 {
+		return
+	}
+	c.authTimer = time.AfterFunc(d, c.reauth)
+}
+
+func (c *realtimeConn) stopAuthRefresh() {
+	c.submitMu.Lock()
+	defer c.submitMu.Unlock()
+	if c.authTimer != nil {
+		c.authTimer.Stop()
+		c.authTimer = nil
+	}
+}
@@ -0,0 +1,162 @@
+package pocketbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+type realtimeAuthServer struct {
+	*httptest.Server
+	mu          sync.Mutex
+	connections int
+	submits     []string // "clientId auth"
+}
+
+func newRealtimeAuthServer() *realtimeAuthServer {
+	s := &realtimeAuthServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			s.mu.Lock()
+			s.connections++
+			n := s.connections
+			s.mu.Unlock()
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "event: PB_CONNECT\ndata: {\"clientId\":\"c%d\"}\n\n", n)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		case http.MethodPost:
+			var body struct {
+				ClientID string `json:"clientId"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			s.mu.Lock()
+			s.submits = append(s.submits, body.ClientID+" "+r.Header.Get("Authorization"))
+			s.mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	return s
+}
+
+// waitSubmits waits for n subscription requests and returns them.
+func (s *realtimeAuthServer) waitSubmits(t *testing.T, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s.mu.Lock()
+		submits := append([]string(nil), s.submits...)
+		s.mu.Unlock()
+		if len(submits) >= n {
+			return submits
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d subscription requests, got %v", n, submits)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRealtimeFollowsAuthChanges(t *testing.T) {
+	srv := newRealtimeAuthServer()
+	defer srv.Close()
+
+	secret := []byte("secret")
+	exp := time.Now().Add(time.Hour)
+	alice := newAuthTokenForTest(t, secret, "alice", "users", exp)
+	aliceRefreshed := newAuthTokenForTest(t, secret, "alice", "users", exp.Add(time.Minute))
+	bob := newAuthTokenForTest(t, secret, "bob", "users", exp)
+
+	c := NewClient(srv.URL, WithRealtimeOptions(RealtimeOptions{
+		Reconnect: ReconnectPolicy{InitialDelay: 10 * time.Millisecond},
+	}))
+	c.WithToken(alice)
+
+	errs := make(chan error, 1)
+	unsub, err := c.Realtime.Subscribe(context.Background(), []string{"posts"}, func(_ *RealtimeEvent, err error) {
+		if err != nil {
+			errs <- err
+		}
+	})
+	if err != nil {
+		t.Fatalf("subscribe err: %v", err)
+	}
+	defer unsub()
+
+	// A new token for the same record is submitted on the same stream.
+	c.WithToken(aliceRefreshed)
+	if got := srv.waitSubmits(t, 2)[1]; got != "c1 "+aliceRefreshed {
+		t.Fatalf("expected resubmit on c1 with the new token, got %q", got)
+	}
+
+	// A different record needs a new stream.
+	c.WithToken(bob)
+	if got := srv.waitSubmits(t, 3)[2]; got != "c2 "+bob {
+		t.Fatalf("expected a new stream authorized as bob, got %q", got)
+	}
+
+	c.ClearAuthStore()
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrRealtimeAuthCleared) {
+			t.Fatalf("expected ErrRealtimeAuthCleared, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("subscription not closed after ClearAuthStore")
+	}
+	if st := c.Realtime.(*RealtimeService).Status(context.Background()); st.State != RealtimeClosed || !errors.Is(st.Err, ErrRealtimeAuthCleared) {
+		t.Fatalf("expected a closed connection after ClearAuthStore, got %+v", st)
+	}
+}
+
+// swappableAuth hands out whatever token it currently holds, like a strategy
+// that refreshes in the background.
+type swappableAuth struct {
+	mu    sync.Mutex
+	token string
+}
+
+func (a *swappableAuth) Token(*Client) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.token, nil
+}
+
+func (a *swappableAuth) Clear() {}
+
+func (a *swappableAuth) set(token string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = token
+}
+
+func TestRealtimeResubmitsBeforeTokenExpiry(t *testing.T) {
+	srv := newRealtimeAuthServer()
+	defer srv.Close()
+
+	secret := []byte("secret")
+	// Resubmission is due tokenExpiryLeeway/2 before expiry.
+	first := newAuthTokenForTest(t, secret, "svc", "services", time.Now().Add(tokenExpiryLeeway/2+time.Second))
+	second := newAuthTokenForTest(t, secret, "svc", "services", time.Now().Add(time.Hour))
+
+	auth := &swappableAuth{token: first}
+	c := NewClient(srv.URL, WithAuthStrategy(auth))
+	unsub, err := c.Realtime.Subscribe(context.Background(), []string{"posts"}, func(*RealtimeEvent, error) {})
+	if err != nil {
+		t.Fatalf("subscribe err: %v", err)
+	}
+	defer unsub()
+
+	auth.set(second)
+	if got := srv.waitSubmits(t, 2)[1]; got != "c1 "+second {
+		t.Fatalf("expected resubmit with the refreshed token, got %q", got)
+	}
+}
@@ -0,0 +1,245 @@
+package pocketbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// ErrRealtimeGap matches a *RealtimeGapError with errors.Is.
+var ErrRealtimeGap = errors.New("pocketbase: realtime events may have been missed")
+
+// RealtimeGapError is reported to catch-up subscriptions after a reconnect.
+// Creates and updates made while disconnected have been replayed, but
+// deletes can't be recovered, so caches should be revalidated if that matters.
+type RealtimeGapError struct {
+	// Topics are the subscription topics that were caught up.
+	Topics []string
+	// Err is set if the catch-up queries failed, in which case creates and
+	// updates may have been missed as well.
+	Err error
+}
+
+func (e *RealtimeGapError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("pocketbase: realtime catch-up for %s failed: %v", strings.Join(e.Topics, ", "), e.Err)
+	}
+	return fmt.Sprintf("pocketbase: deletes on %s may have been missed while reconnecting", strings.Join(e.Topics, ", "))
+}
+
+func (e *RealtimeGapError) Unwrap() error { return e.Err }
+
+func (e *RealtimeGapError) Is(target error) bool { return target == ErrRealtimeGap }
+
+// catchUpPerPage is the page size of catch-up queries.
+const catchUpPerPage = 200
+
+// catchUpClockSkew is subtracted from the local clock to seed the cursors,
+// since the server's "updated" times may lag behind it. Records updated this
+// long before subscribing may be replayed by the first catch-up.
+const catchUpClockSkew = time.Minute
+
+// catchUpFields lists the fields catch-up relies on.
+var catchUpFields = []string{"id", "created", "updated"}
+
+// withCatchUpFields returns o with the fields catch-up relies on added to a
+// restricted Fields list, so that events and catch-up queries include them.
+func (o *SubscribeOptions) withCatchUpFields() *SubscribeOptions {
+	if o == nil || !o.CatchUp || o.Fields == "" {
+		return o
+	}
+	listed := strings.Split(o.Fields, ",")
+	for i := range listed {
+		listed[i] = strings.TrimSpace(listed[i])
+	}
+	if slices.Contains(listed, "*") {
+		return o
+	}
+	opts := *o
+	for _, field := range catchUpFields {
+		if !slices.Contains(listed, field) {
+			opts.Fields += "," + field
+		}
+	}
+	return &opts
+}
+
+// realtimeCatchUp replays the record changes a listener missed while its
+// connection was down. It tracks the latest "updated" time seen per
+// collection and, after a reconnect, lists the records updated since.
+type realtimeCatchUp struct {
+	records RecordServiceAPI
+	topics  []string
+	opts    *SubscribeOptions
+
+	mu      sync.Mutex
+	cursors map[string]*catchUpCursor // keyed by the collection of the topic
+}
+
+// catchUpCursor is the position of a collection in the event stream.
+type catchUpCursor struct {
+	since types.DateTime
+	ids   map[string]struct{} // records already seen at since
+}
+
+func newRealtimeCatchUp(client *Client, topics []string, opts *SubscribeOptions) *realtimeCatchUp {
+	c := &realtimeCatchUp{
+		records: client.Records,
+		opts:    opts,
+		cursors: make(map[string]*catchUpCursor),
+	}
+	// The cursors move to the server's "updated" times with the first event;
+	// until then the margin covers clock skew. The ids recorded at a cursor
+	// keep records from being delivered twice.
+	since := types.NowDateTime().Add(-catchUpClockSkew)
+	for _, topic := range topics {
+		collection, _, ok := splitRecordTopic(topic)
+		if !ok {
+			continue // not a record topic
+		}
+		c.topics = append(c.topics, topic)
+		c.cursors[collection] = &catchUpCursor{since: since, ids: map[string]struct{}{}}
+	}
+	return c
+}
+
+// splitRecordTopic splits "collection/*" or "collection/recordID".
+// recordID is empty for collection-wide topics.
+func splitRecordTopic(topic string) (collection, recordID string, ok bool) {
+	topic, _, _ = strings.Cut(topic, "?")
+	collection, recordID, ok = strings.Cut(topic, "/")
+	if !ok || collection == "" || recordID == "" {
+		return "", "", false
+	}
+	if recordID == "*" {
+		recordID = ""
+	}
+	return collection, recordID, true
+}
+
+// attach makes l track its position and catch up after reconnects.
+// report receives the gap after every catch-up.
+func (c *realtimeCatchUp) attach(l *realtimeListener, report func(err error)) {
+	if len(c.topics) == 0 {
+		return
+	}
+	deliver := l.onEvent
+	l.onEvent = func(name string, data []byte) {
+		c.observe(data)
+		deliver(name, data)
+	}
+	l.onReconnect = func(ctx context.Context) {
+		report(c.run(ctx, l.onEvent))
+	}
+}
+
+// observe advances the cursor of the event's collection.
+func (c *realtimeCatchUp) observe(data []byte) {
+	var event struct {
+		Record struct {
+			ID             string `json:"id"`
+			CollectionID   string `json:"collectionId"`
+			CollectionName string `json:"collectionName"`
+			Updated        string `json:"updated"`
+		} `json:"record"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil || event.Record.Updated == "" {
+		return
+	}
+	updated, err := types.ParseDateTime(event.Record.Updated)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range []string{event.Record.CollectionName, event.Record.CollectionID} {
+		if cursor, ok := c.cursors[key]; ok {
+			cursor.advance(updated, event.Record.ID)
+		}
+	}
+}
+
+func (cur *catchUpCursor) advance(updated types.DateTime, id string) {
+	switch {
+	case updated.Time().After(cur.since.Time()):
+		cur.since = updated
+		cur.ids = map[string]struct{}{id: {}}
+	case updated.Time().Equal(cur.since.Time()):
+		cur.ids[id] = struct{}{}
+	}
+}
+
+// run lists the records of every topic updated since its cursor and delivers
+// them as create or update events. It always returns a *RealtimeGapError.
+func (c *realtimeCatchUp) run(ctx context.Context, deliver func(name string, data []byte)) error {
+	for _, topic := range c.topics {
+		if err := c.catchUpTopic(ctx, topic, deliver); err != nil {
+			return &RealtimeGapError{Topics: c.topics, Err: err}
+		}
+	}
+	return &RealtimeGapError{Topics: c.topics}
+}
+
+func (c *realtimeCatchUp) catchUpTopic(ctx context.Context, topic string, deliver func(name string, data []byte)) error {
+	collection, recordID, _ := splitRecordTopic(topic)
+	name, err := c.opts.encodeTopic(topic)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	cursor := c.cursors[collection]
+	since := cursor.since
+	seen := make(map[string]struct{}, len(cursor.ids))
+	for id := range cursor.ids {
+		seen[id] = struct{}{}
+	}
+	c.mu.Unlock()
+
+	filter := F("updated").Gte(since)
+	if recordID != "" {
+		filter = filter.And(F("id").Eq(recordID))
+	}
+	opts := &ListOptions{PerPage: catchUpPerPage, Sort: "updated,id", SkipTotal: true}
+	if c.opts != nil {
+		filter = filter.And(FilterExpr{expr: c.opts.Filter})
+		opts.Expand = c.opts.Expand
+		opts.Fields = c.opts.Fields
+		opts.QueryParams = c.opts.Query
+	}
+	opts.Filter = filter.String()
+
+	for page := 1; ; page++ {
+		opts.Page = page
+		result, err := c.records.GetList(ctx, collection, opts)
+		if err != nil {
+			return fmt.Errorf("list %s: %w", collection, err)
+		}
+		for _, record := range result.Items {
+			updated := record.GetDateTime("updated")
+			if _, ok := seen[record.ID]; ok && updated.Time().Equal(since.Time()) {
+				continue
+			}
+			action := RealtimeActionUpdate
+			if created := record.GetDateTime("created"); !created.IsZero() && !created.Time().Before(since.Time()) {
+				action = RealtimeActionCreate
+			}
+			data, err := json.Marshal(&RealtimeEvent{Action: string(action), Record: record})
+			if err != nil {
+				return fmt.Errorf("encode %s record %s: %w", collection, record.ID, err)
+			}
+			deliver(name, data)
+		}
+		if len(result.Items) < catchUpPerPage {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package pocketbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func TestRealtimeCatchUp(t *testing.T) {
+	const (
+		topic   = "posts/*"
+		records = "/api/collections/posts/records"
+	)
+	now := time.Now().UTC()
+	before := now.Add(-time.Hour).Format(types.DefaultDateLayout)
+	seen := now.Add(time.Second).Format(types.DefaultDateLayout)
+	missed := now.Add(5 * time.Second).Format(types.DefaultDateLayout)
+
+	var mu sync.Mutex
+	var connections int
+	var filters []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == records:
+			mu.Lock()
+			filters = append(filters, r.URL.Query().Get("filter"))
+			mu.Unlock()
+			fmt.Fprintf(w, `{"page":1,"perPage":200,"items":[
+				{"id":"r1","collectionName":"posts","created":%q,"updated":%q},
+				{"id":"r2","collectionName":"posts","created":%q,"updated":%q},
+				{"id":"r3","collectionName":"posts","created":%q,"updated":%q}]}`,
+				before, seen, missed, missed, before, missed)
+		case r.Method == http.MethodGet:
+			mu.Lock()
+			connections++
+			n := connections
+			mu.Unlock()
+			w.Header().Set("Content-Type", "text/event-stream")
+			flusher := w.(http.Flusher)
+			fmt.Fprintf(w, "event: PB_CONNECT\ndata: {\"clientId\":\"c%d\"}\n\n", n)
+			flusher.Flush()
+			if n == 1 {
+				time.Sleep(50 * time.Millisecond)
+				fmt.Fprintf(w, "event: %s\ndata: {\"action\":\"update\",\"record\":{\"id\":\"r1\",\"collectionName\":\"posts\",\"updated\":%q}}\n\n", topic, seen)
+				flusher.Flush()
+				time.Sleep(50 * time.Millisecond)
+				return // drop the connection
+			}
+			<-r.Context().Done()
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, WithRealtimeOptions(RealtimeOptions{
+		Reconnect: ReconnectPolicy{InitialDelay: 10 * time.Millisecond},
+	}))
+
+	type result struct {
+		event *RealtimeEvent
+		err   error
+	}
+	results := make(chan result, 10)
+	unsub, err := c.Realtime.(*RealtimeService).SubscribeWithOptions(context.Background(), []string{topic}, &SubscribeOptions{CatchUp: true}, func(ev *RealtimeEvent, err error) {
+		results <- result{ev, err}
+	})
+	if err != nil {
+		t.Fatalf("subscribe err: %v", err)
+	}
+	defer unsub()
+
+	var got []string
+	for len(got) < 4 {
+		select {
+		case r := <-results:
+			switch {
+			case r.err != nil:
+				if !errors.Is(r.err, ErrRealtimeGap) {
+					t.Fatalf("unexpected error: %v", r.err)
+				}
+				var gap *RealtimeGapError
+				if !errors.As(r.err, &gap) || gap.Err != nil {
+					t.Fatalf("expected a successful catch-up, got %v", r.err)
+				}
+				got = append(got, "gap")
+			default:
+				got = append(got, r.event.Action+":"+r.event.Record.ID)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out, got %v", got)
+		}
+	}
+
+	if fmt.Sprint(got) != "[update:r1 create:r2 update:r3 gap]" {
+		t.Fatalf("unexpected events: %v", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(filters) != 1 || filters[0] != "updated >= '"+seen+"'" {
+		t.Fatalf("unexpected catch-up filters: %q", filters)
+	}
+}
+
+func TestSplitRecordTopic(t *testing.T) {
+	tests := []struct {
+		topic, collection, recordID string
+		ok                          bool
+	}{
+		{"posts/*", "posts", "", true},
+		{"posts/abc?options=x", "posts", "abc", true},
+		{"posts", "", "", false},
+		{"/abc", "", "", false},
+	}
+	for _, tt := range tests {
+		collection, recordID, ok := splitRecordTopic(tt.topic)
+		if collection != tt.collection || recordID != tt.recordID || ok != tt.ok {
+			t.Errorf("splitRecordTopic(%q) = %q, %q, %v", tt.topic, collection, recordID, ok)
+		}
+	}
+}
+
+func TestSubscribeOptionsWithCatchUpFields(t *testing.T) {
+	tests := []struct {
+		opts *SubscribeOptions
+		want string
+	}{
+		{&SubscribeOptions{CatchUp: true}, ""},
+		{&SubscribeOptions{CatchUp: true, Fields: "title"}, "title,id,created,updated"},
+		{&SubscribeOptions{CatchUp: true, Fields: "id, updated,title"}, "id, updated,title,created"},
+		{&SubscribeOptions{CatchUp: true, Fields: "*,expand.author.name"}, "*,expand.author.name"},
+		{&SubscribeOptions{Fields: "title"}, "title"},
+	}
+	for _, tt := range tests {
+		orig := tt.opts.Fields
+		if got := tt.opts.withCatchUpFields().Fields; got != tt.want {
+			t.Errorf("withCatchUpFields(%q) = %q, want %q", orig, got, tt.want)
+		}
+		if tt.opts.Fields != orig {
+			t.Errorf("withCatchUpFields modified the options: %q", tt.opts.Fields)
+		}
+	}
+}
+
+func TestRealtimeCatchUpSeedsBeforeLocalClock(t *testing.T) {
+	// A server whose clock lags behind must not have its changes skipped.
+	c := newRealtimeCatchUp(NewClient("http://127.0.0.1:0"), []string{"posts/*"}, &SubscribeOptions{CatchUp: true})
+	serverNow := types.NowDateTime().Add(-catchUpClockSkew / 2)
+	if since := c.cursors["posts"].since; !since.Before(serverNow) {
+		t.Fatalf("cursor %v should be before the lagging server time %v", since, serverNow)
+	}
+}
@@ -0,0 +1,243 @@
+package pocketbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/goccy/go-json"
+)
+
+// ErrSubscriptionOverflow is reported by Subscription.Err when the buffer
+// overflowed under OverflowClose.
+var ErrSubscriptionOverflow = errors.New("pocketbase: realtime subscription buffer overflow")
+
+// OverflowPolicy decides what SubscribeChan does with an event when the
+// subscription's buffer is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits for the consumer. This stalls the shared SSE
+	// connection, and with it every other subscription of the client.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest discards the oldest buffered event to make room.
+	OverflowDropOldest
+	// OverflowDropNewest discards the incoming event.
+	OverflowDropNewest
+	// OverflowClose closes the subscription with ErrSubscriptionOverflow.
+	OverflowClose
+)
+
+// ChanOptions configures SubscribeChan.
+type ChanOptions struct {
+	// BufferSize is the capacity of the events channel. Defaults to 64.
+	BufferSize int
+	// Overflow is applied when the buffer is full. Defaults to OverflowBlock.
+	Overflow OverflowPolicy
+	// Subscribe holds optional per-topic options, see SubscribeWithOptions.
+	Subscribe *SubscribeOptions
+}
+
+// Subscription is a channel-based realtime subscription created by SubscribeChan.
+//
+// Events are buffered so that a slow consumer doesn't hold up the SSE reader.
+// The Events channel is closed when the subscription ends; Err then reports
+// why, or nil if it was closed by Close or its context.
+type Subscription struct {
+	events   chan *RealtimeEvent
+	overflow OverflowPolicy
+
+	dropped atomic.Uint64
+	invalid atomic.Uint64
+	gaps    atomic.Uint64
+
+	// mu guards sending on and closing events; done is closed first so a
+	// blocked send gives way to Close.
+	mu     sync.Mutex
+	closed bool
+	done   chan struct{}
+	once   sync.Once
+
+	// stateMu guards err and unsubscribe separately from mu, which a blocked send holds.
+	stateMu     sync.Mutex
+	err         error
+	unsubscribe UnsubscribeFunc
+}
+
+// SubscribeChan subscribes to topics and delivers events on a buffered channel.
+// See ChanOptions for the buffer size and overflow policy; opts may be nil.
+//
+// The subscription ends when Close is called, ctx is done or reconnecting is
+// given up.
+func (s *RealtimeService) SubscribeChan(ctx context.Context, topics []string, opts *ChanOptions) (*Subscription, error) {
+	size := 64
+	var overflow OverflowPolicy
+	var topicOpts *SubscribeOptions
+	if opts != nil {
+		if opts.BufferSize > 0 {
+			size = opts.BufferSize
+		}
+		overflow = opts.Overflow
+		topicOpts = opts.Subscribe.withCatchUpFields()
+	}
+	encoded, err := topicOpts.encodeTopics(topics)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &Subscription{
+		events:   make(chan *RealtimeEvent, size),
+		overflow: overflow,
+		done:     make(chan struct{}),
+	}
+	l := &realtimeListener{
+		topics:  encoded,
+		onEvent: sub.deliver,
+		onError: func(err error) {
+			// Called after the connection was closed; nothing to unsubscribe.
+			sub.close(err, false)
+		},
+	}
+	if topicOpts != nil && topicOpts.CatchUp {
+		newRealtimeCatchUp(s.Client, topics, topicOpts).attach(l, func(error) {
+			sub.gaps.Add(1)
+		})
+	}
+	unsubscribe, err := s.subscribe(ctx, l)
+	if err != nil {
+		return nil, err
+	}
+
+	sub.stateMu.Lock()
+	sub.unsubscribe = unsubscribe
+	sub.stateMu.Unlock()
+
+	// The subscription may have ended before unsubscribe was stored.
+	select {
+	case <-sub.done:
+		unsubscribe()
+	default:
+		context.AfterFunc(ctx, sub.Close)
+	}
+	return sub, nil
+}
+
+// Events returns the channel on which events are delivered.
+func (s *Subscription) Events() <-chan *RealtimeEvent {
+	return s.events
+}
+
+// Err returns the reason the subscription ended, or nil.
+func (s *Subscription) Err() error {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	return s.err
+}
+
+// Dropped returns the number of events discarded by the overflow policy.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Invalid returns the number of events discarded because they couldn't be decoded.
+func (s *Subscription) Invalid() uint64 {
+	return s.invalid.Load()
+}
+
+// Gaps returns the number of reconnects after which events may be missing.
+// With SubscribeOptions.CatchUp, creates and updates were replayed and only
+// deletes may be missing.
+func (s *Subscription) Gaps() uint64 {
+	return s.gaps.Load()
+}
+
+// Close unsubscribes and closes the Events channel. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.close(nil, true)
+}
+
+// deliver decodes data and buffers it according to the overflow policy.
+// It runs on the SSE reader goroutine.
+func (s *Subscription) deliver(_ string, data []byte) {
+	var event RealtimeEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		s.invalid.Add(1)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+
+	select {
+	case s.events <- &event:
+		return
+	default:
+	}
+
+	switch s.overflow {
+	case OverflowDropOldest:
+		select {
+		case <-s.events:
+			s.dropped.Add(1)
+		default:
+		}
+		select {
+		case s.events <- &event:
+		default:
+			s.dropped.Add(1)
+		}
+	case OverflowDropNewest:
+		s.dropped.Add(1)
+	case OverflowClose:
+		s.dropped.Add(1)
+		s.closeLocked(fmt.Errorf("%w (buffer size %d)", ErrSubscriptionOverflow, cap(s.events)))
+		// Unsubscribing submits the topic set, which must not happen on the reader goroutine.
+		if fn := s.unsubscribeFunc(); fn != nil {
+			go fn()
+		}
+	default:
+		select {
+		case s.events <- &event:
+		case <-s.done:
+		}
+	}
+}
+
+// close ends the subscription with err, unsubscribing if requested.
+func (s *Subscription) close(err error, unsubscribe bool) {
+	s.once.Do(func() { close(s.done) })
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closeLocked(err)
+	s.mu.Unlock()
+
+	if fn := s.unsubscribeFunc(); unsubscribe && fn != nil {
+		fn()
+	}
+}
+
+func (s *Subscription) unsubscribeFunc() UnsubscribeFunc {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	return s.unsubscribe
+}
+
+// closeLocked marks the subscription closed. s.mu must be held.
+func (s *Subscription) closeLocked(err error) {
+	s.once.Do(func() { close(s.done) })
+	s.stateMu.Lock()
+	s.err = err
+	s.stateMu.Unlock()
+
+	s.closed = true
+	close(s.events)
+}
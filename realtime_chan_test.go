@@ -0,0 +1,147 @@
+package pocketbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newBurstServer emits n "posts" events right after the topics are submitted.
+func newBurstServer(t *testing.T, n int) *httptest.Server {
+	t.Helper()
+	submitted := make(chan struct{}, 1)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "text/event-stream")
+			flusher := w.(http.Flusher)
+			_, _ = io.WriteString(w, "event: PB_CONNECT\ndata: {\"clientId\":\"c1\"}\n\n")
+			flusher.Flush()
+			select {
+			case <-submitted:
+			case <-r.Context().Done():
+				return
+			}
+			for i := 1; i <= n; i++ {
+				fmt.Fprintf(w, "event: posts\ndata: {\"action\":\"create\",\"record\":{\"id\":\"r%d\"}}\n\n", i)
+			}
+			flusher.Flush()
+			<-r.Context().Done()
+		case http.MethodPost:
+			w.WriteHeader(http.StatusNoContent)
+			select {
+			case submitted <- struct{}{}:
+			default:
+			}
+		}
+	}))
+}
+
+// waitDropped waits until sub has dropped want events.
+func waitDropped(t *testing.T, sub *Subscription, want uint64) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for sub.Dropped() < want && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := sub.Dropped(); got != want {
+		t.Fatalf("expected %d dropped events, got %d", want, got)
+	}
+}
+
+func receiveIDs(t *testing.T, sub *Subscription, n int) []string {
+	t.Helper()
+	var ids []string
+	for i := 0; i < n; i++ {
+		select {
+		case ev := <-sub.Events():
+			ids = append(ids, ev.Record.ID)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out after %d events", i)
+		}
+	}
+	return ids
+}
+
+func TestSubscribeChanOverflowPolicies(t *testing.T) {
+	tests := []struct {
+		policy OverflowPolicy
+		want   string
+	}{
+		{OverflowDropNewest, "[r1 r2]"},
+		{OverflowDropOldest, "[r4 r5]"},
+	}
+	for _, tt := range tests {
+		srv := newBurstServer(t, 5)
+		c := NewClient(srv.URL)
+		sub, err := c.Realtime.(*RealtimeService).SubscribeChan(context.Background(), []string{"posts"}, &ChanOptions{BufferSize: 2, Overflow: tt.policy})
+		if err != nil {
+			t.Fatalf("SubscribeChan failed: %v", err)
+		}
+		waitDropped(t, sub, 3)
+		if got := fmt.Sprint(receiveIDs(t, sub, 2)); got != tt.want {
+			t.Errorf("policy %d: expected %s, got %s", tt.policy, tt.want, got)
+		}
+		sub.Close()
+		if _, ok := <-sub.Events(); ok {
+			t.Errorf("policy %d: events channel should be closed", tt.policy)
+		}
+		srv.Close()
+	}
+}
+
+func TestSubscribeChanOverflowClose(t *testing.T) {
+	srv := newBurstServer(t, 3)
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	sub, err := c.Realtime.(*RealtimeService).SubscribeChan(context.Background(), []string{"posts"}, &ChanOptions{BufferSize: 1, Overflow: OverflowClose})
+	if err != nil {
+		t.Fatalf("SubscribeChan failed: %v", err)
+	}
+	waitDropped(t, sub, 1)
+
+	var n int
+	for range sub.Events() {
+		n++
+	}
+	if n != 1 {
+		t.Fatalf("expected the buffered event before close, got %d", n)
+	}
+	if !errors.Is(sub.Err(), ErrSubscriptionOverflow) {
+		t.Fatalf("expected overflow error, got %v", sub.Err())
+	}
+}
+
+func TestSubscribeChanBlock(t *testing.T) {
+	srv := newBurstServer(t, 3)
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	sub, err := c.Realtime.(*RealtimeService).SubscribeChan(ctx, []string{"posts"}, &ChanOptions{BufferSize: 1})
+	if err != nil {
+		t.Fatalf("SubscribeChan failed: %v", err)
+	}
+	if got := fmt.Sprint(receiveIDs(t, sub, 3)); got != "[r1 r2 r3]" || sub.Dropped() != 0 {
+		t.Fatalf("expected all events without drops, got %s (%d dropped)", got, sub.Dropped())
+	}
+
+	cancel()
+	select {
+	case _, ok := <-sub.Events():
+		if ok {
+			t.Fatal("unexpected event after cancel")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("events channel not closed after context cancel")
+	}
+	if sub.Err() != nil {
+		t.Fatalf("expected nil error after cancel, got %v", sub.Err())
+	}
+}
@@ -0,0 +1,541 @@
+package pocketbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/tmaxmax/go-sse"
+)
+
+const (
+	realtimePath = "/api/realtime"
+
+	defaultConnectTimeout = 30 * time.Second
+)
+
+// errRealtimeConnClosed is returned by realtimeConn.add when the connection is
+// shutting down; the caller should retry with a new connection.
+var errRealtimeConnClosed = errors.New("pocketbase: realtime connection closed")
+
+// realtimeListener receives the messages of the topics it subscribed to.
+type realtimeListener struct {
+	topics  []string
+	onEvent func(name string, data []byte)
+	onError func(err error)
+	// onReconnect, if set, is called on the reader goroutine after the
+	// topics were submitted on a new stream, before any of its events.
+	onReconnect func(ctx context.Context)
+
+	// active is set once Subscribe returned successfully; only active
+	// listeners are notified when the connection gives up.
+	active bool
+}
+
+// realtimeConn multiplexes the subscriptions of many listeners over a single
+// SSE connection to /api/realtime. The connection is opened by the first
+// listener, the subscription set is re-submitted whenever listeners change
+// and it is closed when the last listener unsubscribes.
+type realtimeConn struct {
+	client  *Client
+	service *RealtimeService
+	key     AuthStrategy
+	policy  ReconnectPolicy
+
+	// ctx bounds the lifetime of the connection, across reconnects.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	listeners map[*realtimeListener]struct{}
+	started   bool
+	closed    bool
+	clientID  string
+	ready     chan struct{} // closed once the current stream's topics are submitted
+	done      chan struct{} // closed when the connection gave up or was closed
+	err       error         // reason the connection gave up
+
+	// submitMu serializes subscription requests so an older topic set can't
+	// overwrite a newer one. submitted is the topic set known to the server.
+	submitMu  sync.Mutex
+	submitted string
+	// submittedToken is the token of the last subscription request, which
+	// the server associated with the client id. Guarded by submitMu.
+	submittedToken string
+	authTimer      *time.Timer
+
+	// streamCancel ends the current stream; restart makes run reconnect
+	// right away instead of counting the end of the stream as a failure.
+	streamCancel context.CancelFunc
+	restart      atomic.Bool
+	// closeErr is reported to the listeners when the connection is shut down.
+	closeErr error
+
+	// Reported by status; guarded by mu.
+	state      RealtimeState
+	lastEvent  time.Time
+	reconnects int
+	lastErr    error
+}
+
+func newRealtimeConn(s *RealtimeService, key AuthStrategy) *realtimeConn {
+	ctx := context.Background()
+	if key != nil {
+		ctx = ContextWithAuth(ctx, key)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return &realtimeConn{
+		client:    s.Client,
+		service:   s,
+		key:       key,
+		policy:    s.Options.Reconnect.withDefaults(),
+		ctx:       ctx,
+		cancel:    cancel,
+		listeners: make(map[*realtimeListener]struct{}),
+		ready:     make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// add registers l, starting the connection if needed, and waits until the
+// server knows about its topics. On success it returns a function that
+// removes the listener again.
+func (c *realtimeConn) add(ctx context.Context, l *realtimeListener, timeout time.Duration) (UnsubscribeFunc, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, errRealtimeConnClosed
+	}
+	c.listeners[l] = struct{}{}
+	if !c.started {
+		c.started = true
+		go c.run()
+	}
+	ready := c.ready
+	c.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	// Wait for the subscription to be confirmed or fail
+	select {
+	case <-ready:
+	case <-c.done:
+		c.remove(l)
+		if c.err != nil {
+			return nil, c.err
+		}
+		return nil, errRealtimeConnClosed
+	case <-ctx.Done():
+		c.remove(l)
+		return nil, ctx.Err()
+	case <-timer.C:
+		c.remove(l)
+		return nil, fmt.Errorf("pocketbase: subscribe timeout waiting for PB_CONNECT")
+	}
+
+	if err := c.sync(ctx); err != nil {
+		c.remove(l)
+		return nil, err
+	}
+
+	c.mu.Lock()
+	l.active = true
+	c.mu.Unlock()
+
+	var once sync.Once
+	return func() { once.Do(func() { c.remove(l) }) }, nil
+}
+
+// remove unregisters l. The connection is closed when no listeners remain,
+// otherwise the reduced topic set is submitted.
+func (c *realtimeConn) remove(l *realtimeListener) {
+	c.mu.Lock()
+	if _, ok := c.listeners[l]; !ok {
+		c.mu.Unlock()
+		return
+	}
+	delete(c.listeners, l)
+	last := len(c.listeners) == 0 && !c.closed
+	if last {
+		c.closed = true
+	}
+	c.mu.Unlock()
+
+	if last {
+		c.service.drop(c, nil)
+		c.cancel()
+		return
+	}
+	_ = c.sync(c.ctx)
+}
+
+// topicsLocked returns the sorted, de-duplicated topics of all listeners.
+// c.mu must be held.
+func (c *realtimeConn) topicsLocked() []string {
+	var topics []string
+	for l := range c.listeners {
+		topics = append(topics, l.topics...)
+	}
+	slices.Sort(topics)
+	return slices.Compact(topics)
+}
+
+// sync submits the current topic set if it differs from the one known to the server.
+func (c *realtimeConn) sync(ctx context.Context) error {
+	c.submitMu.Lock()
+	defer c.submitMu.Unlock()
+	return c.submitLocked(ctx)
+}
+
+func (c *realtimeConn) submitLocked(ctx context.Context) error {
+	c.mu.Lock()
+	clientID := c.clientID
+	topics := c.topicsLocked()
+	c.mu.Unlock()
+
+	// Without a client id the topics are submitted on the next PB_CONNECT.
+	if clientID == "" {
+		return nil
+	}
+	key := strings.Join(topics, "\n")
+	if key == c.submitted {
+		return nil
+	}
+
+	if topics == nil {
+		topics = []string{}
+	}
+	// Resolve the token up front to know the identity the server will see;
+	// the request itself picks up the same cached token.
+	token, err := c.client.currentToken(ctx)
+	if err != nil {
+		return fmt.Errorf("pocketbase: failed to send subscription request: %w", err)
+	}
+	body := map[string]any{"clientId": clientID, "subscriptions": topics}
+	if err := c.client.send(ctx, http.MethodPost, realtimePath, body, nil); err != nil {
+		return fmt.Errorf("pocketbase: failed to send subscription request: %w", err)
+	}
+	c.submitted = key
+	c.submittedToken = token
+	c.scheduleAuthRefresh(token)
+	return nil
+}
+
+// connected handles PB_CONNECT: it stores the new client id and submits all topics.
+func (c *realtimeConn) connected(ctx context.Context, data string) error {
+	var connectEvent struct {
+		ClientID string `json:"clientId"`
+	}
+	if err := json.Unmarshal([]byte(data), &connectEvent); err != nil {
+		return fmt.Errorf("pocketbase: failed to unmarshal PB_CONNECT event: %w", err)
+	}
+	if connectEvent.ClientID == "" {
+		return fmt.Errorf("pocketbase: PB_CONNECT event missing clientId")
+	}
+
+	c.submitMu.Lock()
+	defer c.submitMu.Unlock()
+
+	c.mu.Lock()
+	c.clientID = connectEvent.ClientID
+	c.mu.Unlock()
+	c.submitted = ""
+	c.submittedToken = ""
+	if err := c.submitLocked(ctx); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	select {
+	case <-c.ready:
+	default:
+		close(c.ready)
+	}
+	c.mu.Unlock()
+	return nil
+}
+
+// disconnected resets the per-stream state after the SSE stream ended with err.
+func (c *realtimeConn) disconnected(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clientID = ""
+	c.lastErr = err
+	select {
+	case <-c.ready:
+		c.ready = make(chan struct{})
+	default:
+	}
+}
+
+// status returns a snapshot of the connection's state.
+func (c *realtimeConn) status() RealtimeStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.statusLocked()
+}
+
+func (c *realtimeConn) statusLocked() RealtimeStatus {
+	return RealtimeStatus{
+		State:      c.state,
+		ClientID:   c.clientID,
+		LastEvent:  c.lastEvent,
+		Reconnects: c.reconnects,
+		Err:        c.lastErr,
+	}
+}
+
+// setState moves the connection to state and reports the change.
+func (c *realtimeConn) setState(state RealtimeState) {
+	c.mu.Lock()
+	c.state = state
+	status := c.statusLocked()
+	c.mu.Unlock()
+
+	if fn := c.service.Options.OnStateChange; fn != nil {
+		fn(status)
+	}
+}
+
+// reconnected runs the reconnect hooks of the active listeners.
+func (c *realtimeConn) reconnected(ctx context.Context) {
+	c.mu.Lock()
+	var hooks []func(context.Context)
+	for l := range c.listeners {
+		if l.active && l.onReconnect != nil {
+			hooks = append(hooks, l.onReconnect)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook(ctx)
+	}
+}
+
+// dispatch delivers a message to the listeners subscribed to its topic.
+// Messages without a name are delivered to every listener.
+func (c *realtimeConn) dispatch(name string, data []byte) {
+	c.mu.Lock()
+	var targets []*realtimeListener
+	for l := range c.listeners {
+		if name == "" || name == "message" || slices.Contains(l.topics, name) {
+			targets = append(targets, l)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, l := range targets {
+		l.onEvent(name, data)
+	}
+}
+
+// run connects and reconnects until the connection is closed or the policy gives up.
+// A failure of the very first attempt is not retried so that Subscribe can report it.
+func (c *realtimeConn) run() {
+	c.setState(RealtimeConnecting)
+	everConnected := false
+	failures := 0
+	for {
+		established, err := c.connectOnce(everConnected)
+		c.disconnected(err)
+		if c.ctx.Err() != nil {
+			c.finish(c.shutdownErr())
+			return
+		}
+		if c.restart.Swap(false) {
+			c.setState(RealtimeReconnecting)
+			continue
+		}
+		if established {
+			everConnected = true
+			failures = 0
+		}
+		if !everConnected {
+			c.finish(err)
+			return
+		}
+		if !c.policy.allows(failures) {
+			c.finish(fmt.Errorf("pocketbase: sse subscription failed: %w", err))
+			return
+		}
+		c.setState(RealtimeReconnecting)
+
+		select {
+		case <-time.After(c.policy.delay(failures)):
+		case <-c.ctx.Done():
+			c.finish(c.shutdownErr())
+			return
+		}
+		failures++
+	}
+}
+
+// finish closes the connection for good and notifies active listeners of err.
+func (c *realtimeConn) finish(err error) {
+	c.mu.Lock()
+	c.closed = true
+	c.err = err
+	c.lastErr = err
+	var active []*realtimeListener
+	for l := range c.listeners {
+		if l.active {
+			active = append(active, l)
+		}
+	}
+	close(c.done)
+	c.mu.Unlock()
+
+	c.service.drop(c, err)
+	c.cancel()
+	c.stopAuthRefresh()
+	c.setState(RealtimeClosed)
+
+	if err != nil {
+		for _, l := range active {
+			l.onError(err)
+		}
+	}
+}
+
+// connectOnce opens a single SSE stream and blocks until it ends.
+// established reports whether the topics were submitted successfully.
+// reconnect tells whether an earlier stream was established.
+func (c *realtimeConn) connectOnce(reconnect bool) (established bool, err error) {
+	endpoint, err := url.JoinPath(c.client.BaseURL, realtimePath)
+	if err != nil {
+		return false, fmt.Errorf("pocketbase: invalid realtime path: %w", err)
+	}
+
+	streamCtx, cancel := context.WithCancel(c.ctx)
+	defer cancel()
+	c.mu.Lock()
+	c.streamCancel = cancel
+	c.mu.Unlock()
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return false, fmt.Errorf("pocketbase: failed to create sse request: %w", err)
+	}
+
+	sseHTTPClient := *c.client.HTTPClient
+	sseHTTPClient.Timeout = 0 // Disable timeout for streaming
+
+	// Reconnection is driven by run, so the SSE client must not retry on its own.
+	sseClient := sse.Client{HTTPClient: &sseHTTPClient, Backoff: sse.Backoff{MaxRetries: -1}}
+	conn := sseClient.NewConnection(req)
+
+	// The watchdog ends streams that don't send PB_CONNECT within the
+	// connect timeout, or go silent for longer than the idle timeout.
+	connectTimeout, idleTimeout := c.service.Options.ConnectTimeout, c.service.Options.IdleTimeout
+	if connectTimeout <= 0 {
+		connectTimeout = defaultConnectTimeout
+	}
+	var watchdogErr atomic.Pointer[error]
+	expire := func(err error) func() {
+		return func() {
+			watchdogErr.Store(&err)
+			cancel()
+		}
+	}
+	watchdog := time.AfterFunc(connectTimeout, expire(fmt.Errorf("pocketbase: no PB_CONNECT within %s", connectTimeout)))
+	defer func() { watchdog.Stop() }()
+
+	var submitErr error
+	conn.SubscribeToAll(func(event sse.Event) {
+		c.mu.Lock()
+		c.lastEvent = time.Now()
+		c.mu.Unlock()
+		if idleTimeout > 0 && established {
+			watchdog.Reset(idleTimeout)
+		}
+
+		// --- Connection Handling ---
+		if event.Type == "PB_CONNECT" {
+			if err := c.connected(streamCtx, event.Data); err != nil {
+				submitErr = err
+				cancel()
+				return
+			}
+			established = true
+			watchdog.Stop()
+			if idleTimeout > 0 {
+				watchdog = time.AfterFunc(idleTimeout, expire(fmt.Errorf("pocketbase: realtime stream idle for %s", idleTimeout)))
+			}
+			if reconnect {
+				c.mu.Lock()
+				c.reconnects++
+				c.mu.Unlock()
+			}
+			c.setState(RealtimeConnected)
+			if reconnect {
+				c.reconnected(streamCtx)
+			}
+			return
+		}
+
+		// --- Regular Event Handling ---
+		if len(event.Data) == 0 { // Ignore empty data (e.g., keep-alive messages)
+			return
+		}
+		c.dispatch(event.Type, []byte(event.Data))
+	})
+
+	// Connect() blocks until the connection is closed.
+	err = conn.Connect()
+	if submitErr != nil {
+		return established, submitErr
+	}
+	if werr := watchdogErr.Load(); werr != nil {
+		return established, *werr
+	}
+	if err == nil || errors.Is(err, context.Canceled) && c.ctx.Err() == nil {
+		err = fmt.Errorf("pocketbase: sse connection closed")
+	}
+	return established, err
+}
+
+func (p ReconnectPolicy) withDefaults() ReconnectPolicy {
+	if p.InitialDelay <= 0 {
+		p.InitialDelay = 500 * time.Millisecond
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = 30 * time.Second
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = 2
+	}
+	if p.Jitter == 0 || p.Jitter >= 1 {
+		p.Jitter = 0.5
+	}
+	return p
+}
+
+// allows reports whether another attempt may be made after failures consecutive failures.
+func (p ReconnectPolicy) allows(failures int) bool {
+	return p.MaxRetries == 0 || (p.MaxRetries > 0 && failures < p.MaxRetries)
+}
+
+// delay returns the jittered wait before the attempt following failures consecutive failures.
+func (p ReconnectPolicy) delay(failures int) time.Duration {
+	d := float64(p.InitialDelay)
+	for i := 0; i < failures && d < float64(p.MaxDelay); i++ {
+		d *= p.Multiplier
+	}
+	if d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(d)
+}
@@ -3,12 +3,17 @@ package pocketbase
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/goccy/go-json"
 )
 
 // TestRealtimeServiceSubscribe tests the Subscribe method of RealtimeService.
@@ -80,3 +85,499 @@ func TestRealtimeServiceSubscribe(t *testing.T) {
 		t.Fatalf("subscription body missing: %s", string(body))
 	}
 }
+
+// TestRealtimeServiceReconnect tests that subscriptions are restored after the stream ends.
+func TestRealtimeServiceReconnect(t *testing.T) {
+	var mu sync.Mutex
+	var clientIDs []string
+	var connections int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			mu.Lock()
+			connections++
+			n := connections
+			mu.Unlock()
+			w.Header().Set("Content-Type", "text/event-stream")
+			flusher := w.(http.Flusher)
+			fmt.Fprintf(w, "event: PB_CONNECT\ndata: {\"clientId\":\"client-%d\"}\n\n", n)
+			flusher.Flush()
+			if n == 1 {
+				return // simulate a server restart
+			}
+			time.Sleep(50 * time.Millisecond)
+			_, _ = io.WriteString(w, "event: posts\ndata: {\"action\":\"create\",\"record\":{\"id\":\"rec1\"}}\n\n")
+			flusher.Flush()
+			<-r.Context().Done()
+		case http.MethodPost:
+			var body struct {
+				ClientID      string   `json:"clientId"`
+				Subscriptions []string `json:"subscriptions"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			clientIDs = append(clientIDs, body.ClientID)
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, WithRealtimeOptions(RealtimeOptions{
+		Reconnect: ReconnectPolicy{InitialDelay: 10 * time.Millisecond},
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events := make(chan *RealtimeEvent, 1)
+	unsub, err := c.Realtime.Subscribe(ctx, []string{"posts"}, func(ev *RealtimeEvent, err error) {
+		if err != nil {
+			t.Errorf("callback error: %v", err)
+			return
+		}
+		events <- ev
+	})
+	if err != nil {
+		t.Fatalf("subscribe err: %v", err)
+	}
+	defer unsub()
+
+	select {
+	case ev := <-events:
+		if ev.Action != "create" || ev.Record.ID != "rec1" {
+			t.Fatalf("unexpected event: %+v", ev)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for event after reconnect")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(clientIDs) != 2 || clientIDs[0] != "client-1" || clientIDs[1] != "client-2" {
+		t.Fatalf("expected topics to be re-submitted with the new client id, got %v", clientIDs)
+	}
+}
+
+// TestRealtimeServiceReconnectGivesUp tests that the callback is notified when retries are exhausted.
+func TestRealtimeServiceReconnectGivesUp(t *testing.T) {
+	var mu sync.Mutex
+	connections := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		mu.Lock()
+		connections++
+		n := connections
+		mu.Unlock()
+		if n > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "event: PB_CONNECT\ndata: {\"clientId\":\"client-1\"}\n\n")
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, WithRealtimeOptions(RealtimeOptions{
+		Reconnect: ReconnectPolicy{MaxRetries: 2, InitialDelay: time.Millisecond, Jitter: -1},
+	}))
+	errs := make(chan error, 1)
+	unsub, err := c.Realtime.Subscribe(context.Background(), []string{"posts"}, func(ev *RealtimeEvent, err error) {
+		if err != nil {
+			errs <- err
+		}
+	})
+	if err != nil {
+		t.Fatalf("subscribe err: %v", err)
+	}
+	defer unsub()
+
+	var gaveUp error
+	select {
+	case gaveUp = <-errs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected an error after retries were exhausted")
+	}
+	mu.Lock()
+	if connections != 3 {
+		t.Fatalf("expected 1 connection and 2 retries, got %d connections", connections)
+	}
+	mu.Unlock()
+
+	// The final status stays readable until a new connection replaces it.
+	rs := c.Realtime.(*RealtimeService)
+	status := rs.Status(context.Background())
+	if status.State != RealtimeClosed || status.Err == nil || status.Err.Error() != gaveUp.Error() {
+		t.Fatalf("expected the closed status with the error, got %+v", status)
+	}
+}
+
+// TestRealtimeServiceMultiplex tests that subscriptions share a single connection.
+func TestRealtimeServiceMultiplex(t *testing.T) {
+	var mu sync.Mutex
+	var posts [][]string
+	connections := 0
+	closed := make(chan struct{})
+	send := make(chan string, 4)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			mu.Lock()
+			connections++
+			mu.Unlock()
+			w.Header().Set("Content-Type", "text/event-stream")
+			flusher := w.(http.Flusher)
+			_, _ = io.WriteString(w, "event: PB_CONNECT\ndata: {\"clientId\":\"shared\"}\n\n")
+			flusher.Flush()
+			for {
+				select {
+				case msg := <-send:
+					_, _ = io.WriteString(w, msg)
+					flusher.Flush()
+				case <-r.Context().Done():
+					close(closed)
+					return
+				}
+			}
+		case http.MethodPost:
+			var body struct {
+				Subscriptions []string `json:"subscriptions"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			posts = append(posts, body.Subscriptions)
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	ctx := context.Background()
+
+	postsEvents := make(chan *RealtimeEvent, 1)
+	unsubPosts, err := c.Realtime.Subscribe(ctx, []string{"posts/*"}, func(ev *RealtimeEvent, err error) {
+		postsEvents <- ev
+	})
+	if err != nil {
+		t.Fatalf("subscribe posts: %v", err)
+	}
+	usersEvents := make(chan *RealtimeEvent, 1)
+	unsubUsers, err := c.Realtime.Subscribe(ctx, []string{"users/*"}, func(ev *RealtimeEvent, err error) {
+		usersEvents <- ev
+	})
+	if err != nil {
+		t.Fatalf("subscribe users: %v", err)
+	}
+
+	send <- "event: users/*\ndata: {\"action\":\"update\",\"record\":{\"id\":\"u1\"}}\n\n"
+	select {
+	case ev := <-usersEvents:
+		if ev.Record.ID != "u1" {
+			t.Fatalf("unexpected event: %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for users event")
+	}
+	select {
+	case ev := <-postsEvents:
+		t.Fatalf("posts listener must not receive users events: %+v", ev)
+	default:
+	}
+
+	unsubPosts()
+	unsubUsers()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("connection should be closed after the last unsubscribe")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if connections != 1 {
+		t.Fatalf("expected a single connection, got %d", connections)
+	}
+	want := [][]string{{"posts/*"}, {"posts/*", "users/*"}, {"users/*"}}
+	if fmt.Sprint(posts) != fmt.Sprint(want) {
+		t.Fatalf("unexpected subscription requests: %v", posts)
+	}
+}
+
+// TestTypedRecordServiceSubscribe tests that events are decoded into the model type.
+func TestTypedRecordServiceSubscribe(t *testing.T) {
+	var mu sync.Mutex
+	var subscriptions []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "text/event-stream")
+			flusher := w.(http.Flusher)
+			_, _ = io.WriteString(w, "event: PB_CONNECT\ndata: {\"clientId\":\"c1\"}\n\n")
+			flusher.Flush()
+			time.Sleep(20 * time.Millisecond)
+			_, _ = io.WriteString(w, "event: posts/rec1\ndata: {\"action\":\"update\",\"record\":{\"id\":\"rec1\",\"collectionName\":\"posts\",\"title\":\"Hello\"}}\n\n")
+			flusher.Flush()
+			<-r.Context().Done()
+		case http.MethodPost:
+			var body struct {
+				Subscriptions []string `json:"subscriptions"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			subscriptions = body.Subscriptions
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	posts := NewTypedRecordService[testPost](c, "posts")
+
+	events := make(chan TypedRealtimeEvent[testPost], 1)
+	unsub, err := posts.SubscribeRecord(context.Background(), "rec1", func(ev TypedRealtimeEvent[testPost], err error) {
+		if err != nil {
+			t.Errorf("callback error: %v", err)
+			return
+		}
+		events <- ev
+	})
+	if err != nil {
+		t.Fatalf("subscribe err: %v", err)
+	}
+	defer unsub()
+
+	select {
+	case ev := <-events:
+		if ev.Action != RealtimeActionUpdate || ev.Record == nil || ev.Record.Title != "Hello" || ev.Record.ID != "rec1" {
+			t.Fatalf("unexpected event: %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for typed event")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(subscriptions) != 1 || subscriptions[0] != "posts/rec1" {
+		t.Fatalf("unexpected subscriptions: %v", subscriptions)
+	}
+}
+
+func TestSubscribeOptionsEncodeTopic(t *testing.T) {
+	opts := &SubscribeOptions{
+		Filter:  "status = 'published'",
+		Expand:  "author",
+		Query:   map[string]string{"lang": "en"},
+		Headers: map[string]string{"x-token": "abc"},
+	}
+	topic, err := opts.encodeTopic("posts/*")
+	if err != nil {
+		t.Fatalf("encodeTopic failed: %v", err)
+	}
+	prefix := "posts/*?options="
+	if len(topic) <= len(prefix) || topic[:len(prefix)] != prefix {
+		t.Fatalf("unexpected topic: %s", topic)
+	}
+	raw, err := url.QueryUnescape(topic[len(prefix):])
+	if err != nil {
+		t.Fatalf("options not escaped: %v", err)
+	}
+	want := `{"query":{"expand":"author","filter":"status = 'published'","lang":"en"},"headers":{"x-token":"abc"}}`
+	if raw != want {
+		t.Fatalf("unexpected options:\n got %s\nwant %s", raw, want)
+	}
+
+	if topic, _ := (&SubscribeOptions{}).encodeTopic("posts"); topic != "posts" {
+		t.Fatalf("empty options should leave the topic unchanged, got %s", topic)
+	}
+}
+
+// TestRealtimeServiceSubscribeWithOptions tests that events named after the
+// encoded topic reach the subscriber.
+func TestRealtimeServiceSubscribeWithOptions(t *testing.T) {
+	submitted := make(chan []string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "text/event-stream")
+			flusher := w.(http.Flusher)
+			_, _ = io.WriteString(w, "event: PB_CONNECT\ndata: {\"clientId\":\"c1\"}\n\n")
+			flusher.Flush()
+			subs := <-submitted
+			// The server names events after the subscription, options included.
+			_, _ = io.WriteString(w, "event: posts/*\ndata: {\"action\":\"create\",\"record\":{\"id\":\"plain\"}}\n\n")
+			fmt.Fprintf(w, "event: %s\ndata: {\"action\":\"create\",\"record\":{\"id\":\"rec1\",\"title\":\"Hi\"}}\n\n", subs[0])
+			flusher.Flush()
+			<-r.Context().Done()
+		case http.MethodPost:
+			var body struct {
+				Subscriptions []string `json:"subscriptions"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			w.WriteHeader(http.StatusNoContent)
+			submitted <- body.Subscriptions
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	posts := NewTypedRecordService[testPost](c, "posts")
+
+	events := make(chan TypedRealtimeEvent[testPost], 2)
+	unsub, err := posts.SubscribeWithOptions(context.Background(), "*", &SubscribeOptions{Expand: "author"}, func(ev TypedRealtimeEvent[testPost], err error) {
+		if err == nil {
+			events <- ev
+		}
+	})
+	if err != nil {
+		t.Fatalf("subscribe err: %v", err)
+	}
+	defer unsub()
+
+	select {
+	case ev := <-events:
+		if ev.Record == nil || ev.Record.ID != "rec1" {
+			t.Fatalf("unexpected event: %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+}
+
+// TestRealtimeServiceIdleTimeout tests that a silent stream is replaced and
+// that state changes are reported.
+func TestRealtimeServiceIdleTimeout(t *testing.T) {
+	var mu sync.Mutex
+	var connections int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			mu.Lock()
+			connections++
+			n := connections
+			mu.Unlock()
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "event: PB_CONNECT\ndata: {\"clientId\":\"c%d\"}\n\n", n)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done() // never send anything else
+		case http.MethodPost:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	states := make(chan RealtimeState, 20)
+	c := NewClient(srv.URL, WithRealtimeOptions(RealtimeOptions{
+		Reconnect:     ReconnectPolicy{InitialDelay: 10 * time.Millisecond},
+		IdleTimeout:   100 * time.Millisecond,
+		OnStateChange: func(s RealtimeStatus) { states <- s.State },
+	}))
+	ctx := context.Background()
+	if got := c.Realtime.(*RealtimeService).Status(ctx).State; got != RealtimeDisconnected {
+		t.Fatalf("expected disconnected before subscribing, got %s", got)
+	}
+
+	unsub, err := c.Realtime.Subscribe(ctx, []string{"posts"}, func(*RealtimeEvent, error) {})
+	if err != nil {
+		t.Fatalf("subscribe err: %v", err)
+	}
+
+	want := []RealtimeState{RealtimeConnecting, RealtimeConnected, RealtimeReconnecting, RealtimeConnected}
+	for i, w := range want {
+		select {
+		case got := <-states:
+			if got != w {
+				t.Fatalf("state %d: expected %s, got %s", i, w, got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for state %s", w)
+		}
+	}
+
+	status := c.Realtime.(*RealtimeService).Status(ctx)
+	if status.State != RealtimeConnected || status.ClientID != "c2" || status.Reconnects != 1 || status.LastEvent.IsZero() {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	if status.Err == nil || !strings.Contains(status.Err.Error(), "idle") {
+		t.Fatalf("expected the idle timeout as last error, got %v", status.Err)
+	}
+
+	unsub()
+	select {
+	case got := <-states:
+		for got == RealtimeReconnecting || got == RealtimeConnected {
+			got = <-states // the idle timer may fire again before unsubscribing
+		}
+		if got != RealtimeClosed {
+			t.Fatalf("expected closed, got %s", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for close")
+	}
+}
+
+// TestRealtimeServiceSubscribeRaw tests delivery of custom messages.
+func TestRealtimeServiceSubscribeRaw(t *testing.T) {
+	submitted := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "text/event-stream")
+			flusher := w.(http.Flusher)
+			_, _ = io.WriteString(w, "event: PB_CONNECT\ndata: {\"clientId\":\"c1\"}\n\n")
+			flusher.Flush()
+			<-submitted
+			_, _ = io.WriteString(w, "event: chat/room1\ndata: {\"text\":\"hi\",\"from\":\"bob\"}\n\n")
+			flusher.Flush()
+			<-r.Context().Done()
+		case http.MethodPost:
+			w.WriteHeader(http.StatusNoContent)
+			submitted <- struct{}{}
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	msgs := make(chan *RealtimeMessage, 1)
+	unsub, err := c.Realtime.(*RealtimeService).SubscribeRaw(context.Background(), []string{"chat/room1"}, func(msg *RealtimeMessage, err error) {
+		if err != nil {
+			t.Errorf("callback error: %v", err)
+			return
+		}
+		msgs <- msg
+	})
+	if err != nil {
+		t.Fatalf("subscribe err: %v", err)
+	}
+	defer unsub()
+
+	var msg *RealtimeMessage
+	select {
+	case msg = <-msgs:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+	if msg.Topic != "chat/room1" {
+		t.Fatalf("unexpected topic: %s", msg.Topic)
+	}
+
+	type chatMessage struct {
+		Text string `json:"text"`
+		From string `json:"from"`
+	}
+	decoded, err := DecodeMessage[chatMessage](msg)
+	if err != nil {
+		t.Fatalf("DecodeMessage failed: %v", err)
+	}
+	if decoded.Text != "hi" || decoded.From != "bob" {
+		t.Fatalf("unexpected message: %+v", decoded)
+	}
+	if _, err := DecodeMessage[chatMessage](&RealtimeMessage{Topic: "chat/room1", Data: []byte("nope")}); err == nil {
+		t.Fatal("expected decode error")
+	}
+}
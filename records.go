@@ -250,33 +250,15 @@ func (s *TypedRecordService[T]) GetList(ctx context.Context, opts *ListOptions)
 	}, nil
 }
 
-// GetAll retrieves all records (auto-pagination).
+// GetAll retrieves all records matching opts (auto-pagination).
+// opts may be nil. Use Iterate to process large collections page by page.
 func (s *TypedRecordService[T]) GetAll(ctx context.Context, opts *ListOptions) ([]*T, error) {
 	var all []*T
-	page := 1
-	perPage := 100
-	if opts != nil && opts.PerPage > 0 {
-		perPage = opts.PerPage
-	}
-
-	for {
-		res, err := s.GetList(ctx, &ListOptions{
-			Page:      page,
-			PerPage:   perPage,
-			Filter:    opts.Filter,
-			Sort:      opts.Sort,
-			Expand:    opts.Expand,
-			Fields:    opts.Fields,
-			SkipTotal: opts.SkipTotal,
-		})
+	for item, err := range s.Iterate(ctx, opts) {
 		if err != nil {
 			return nil, err
 		}
-		all = append(all, res.Items...)
-		if page >= res.TotalPages {
-			break
-		}
-		page++
+		all = append(all, item)
 	}
 	return all, nil
 }
@@ -0,0 +1,188 @@
+package pocketbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/goccy/go-json"
+)
+
+// ErrConflict matches a *ConflictError with errors.Is.
+var ErrConflict = errors.New("pocketbase: record was modified concurrently")
+
+// defaultConflictRetries is the number of merge retries when
+// UpdateIfUnchangedOptions.MaxRetries is unset.
+const defaultConflictRetries = 3
+
+// ConflictError is returned by UpdateIfUnchanged when the record changed on
+// the server since the copy the update was based on.
+type ConflictError struct {
+	Collection string
+	RecordID   string
+	// Field is the version field that was compared.
+	Field string
+	// Expected is the version the update was based on, Actual the version on
+	// the server.
+	Expected any
+	Actual   any
+	// Current is the server's copy of the record.
+	Current *Record
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("pocketbase: %s record %s was modified concurrently (%s is %v, expected %v)",
+		e.Collection, e.RecordID, e.Field, e.Actual, e.Expected)
+}
+
+func (e *ConflictError) Is(target error) bool { return target == ErrConflict }
+
+// UpdateIfUnchangedOptions configures RecordService.UpdateIfUnchanged.
+type UpdateIfUnchangedOptions struct {
+	// VersionField is compared between the base record and the server's copy.
+	// The default is "updated". A custom version field should be bumped by
+	// every update, e.g. with body["version+"] = 1.
+	VersionField string
+	// Merge is called on a conflict with the server's copy and the body that
+	// was about to be written. It returns the body to retry with. Without
+	// Merge, conflicts are returned as *ConflictError.
+	Merge func(current *Record, body any) (any, error)
+	// MaxRetries bounds the merge retries (default 3).
+	MaxRetries int
+	// Expand and Fields are applied to the updated record.
+	Expand string
+	Fields string
+}
+
+// UpdateIfUnchanged updates a record only if it hasn't changed on the server
+// since base was read. It re-reads the record and compares the version field
+// of both copies; on a mismatch it returns a *ConflictError holding the
+// server's copy, or, if opts.Merge is set, retries with the merged body.
+//
+// PocketBase has no conditional writes, so a change landing between the
+// re-read and the write can't be detected. For a strict guarantee, combine a
+// version field with an update rule such as
+// "@request.body.version = version + 1".
+func (s *RecordService) UpdateIfUnchanged(ctx context.Context, collection string, base *Record, body any, opts *UpdateIfUnchangedOptions) (*Record, error) {
+	var o UpdateIfUnchangedOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.VersionField == "" {
+		o.VersionField = "updated"
+	}
+	if o.MaxRetries <= 0 {
+		o.MaxRetries = defaultConflictRetries
+	}
+	if base == nil || base.ID == "" {
+		return nil, fmt.Errorf("pocketbase: update if unchanged: base record has no id")
+	}
+	expected, err := recordVersion(base, o.VersionField)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		current, err := s.GetOne(ctx, collection, base.ID, nil)
+		if err != nil {
+			return nil, err
+		}
+		actual, err := recordVersion(current, o.VersionField)
+		if err != nil {
+			return nil, err
+		}
+		if reflect.DeepEqual(actual, expected) {
+			return s.UpdateWithOptions(ctx, collection, base.ID, body, &WriteOptions{Expand: o.Expand, Fields: o.Fields})
+		}
+
+		conflict := &ConflictError{
+			Collection: collection,
+			RecordID:   base.ID,
+			Field:      o.VersionField,
+			Expected:   expected,
+			Actual:     actual,
+			Current:    current,
+		}
+		if o.Merge == nil || attempt >= o.MaxRetries {
+			return nil, conflict
+		}
+		if body, err = o.Merge(current, body); err != nil {
+			return nil, fmt.Errorf("pocketbase: merge %s record %s: %w", collection, base.ID, err)
+		}
+		expected = actual
+	}
+}
+
+// recordVersion returns the JSON value of field in v, a *Record or a
+// generated model.
+func recordVersion(v any, field string) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("pocketbase: encode record: %w", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("pocketbase: decode record: %w", err)
+	}
+	value, ok := fields[field]
+	if !ok || value == nil {
+		return nil, fmt.Errorf("pocketbase: record has no %q version field", field)
+	}
+	return value, nil
+}
+
+// TypedUpdateIfUnchangedOptions is the typed version of UpdateIfUnchangedOptions.
+type TypedUpdateIfUnchangedOptions[T any] struct {
+	VersionField string
+	// Merge is called on a conflict with the server's copy and the body that
+	// was about to be written, and returns the body to retry with.
+	Merge      func(current, body *T) (*T, error)
+	MaxRetries int
+	Expand     string
+	Fields     string
+}
+
+// UpdateIfUnchanged updates base with body only if the record hasn't changed
+// on the server since base was read. See RecordService.UpdateIfUnchanged.
+func (s *TypedRecordService[T]) UpdateIfUnchanged(ctx context.Context, base, body *T, opts *TypedUpdateIfUnchangedOptions[T]) (*T, error) {
+	var o UpdateIfUnchangedOptions
+	if opts != nil {
+		o = UpdateIfUnchangedOptions{
+			VersionField: opts.VersionField,
+			MaxRetries:   opts.MaxRetries,
+			Expand:       opts.Expand,
+			Fields:       opts.Fields,
+		}
+		if merge := opts.Merge; merge != nil {
+			o.Merge = func(current *Record, body any) (any, error) {
+				cur, err := convertRecord[T](current)
+				if err != nil {
+					return nil, err
+				}
+				return merge(cur, body.(*T))
+			}
+		}
+	}
+
+	id, err := recordVersion(base, "id")
+	if err != nil {
+		return nil, err
+	}
+	version := o.VersionField
+	if version == "" {
+		version = "updated"
+	}
+	baseVersion, err := recordVersion(base, version)
+	if err != nil {
+		return nil, err
+	}
+	baseRec := &Record{ID: fmt.Sprint(id)}
+	baseRec.Set(version, baseVersion)
+
+	rec, err := s.RecordService.UpdateIfUnchanged(ctx, s.Collection, baseRec, body, &o)
+	if err != nil {
+		return nil, err
+	}
+	return convertRecord[T](rec)
+}
@@ -0,0 +1,173 @@
+package pocketbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/goccy/go-json"
+)
+
+// versionedServer serves a single record "r1" whose "updated" field changes on
+// every write. If bumpOnRead is set, every read also simulates a concurrent
+// write.
+type versionedServer struct {
+	mu         sync.Mutex
+	data       map[string]any
+	version    int
+	patches    int
+	bumpOnRead bool
+}
+
+func (v *versionedServer) bump() {
+	v.version++
+	v.data["updated"] = fmt.Sprintf("2024-01-01 00:00:%02d.000Z", v.version)
+}
+
+func newVersionedServer(t *testing.T, bumpOnRead bool) (*versionedServer, *Client) {
+	v := &versionedServer{data: map[string]any{"id": "r1", "title": "a", "count": float64(0)}, bumpOnRead: bumpOnRead}
+	v.bump()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		if r.URL.Path != "/api/collections/posts/records/r1" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		switch r.Method {
+		case http.MethodGet:
+			if v.bumpOnRead {
+				v.bump()
+			}
+		case http.MethodPatch:
+			v.patches++
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			for k, val := range body {
+				v.data[k] = val
+			}
+			v.bump()
+		}
+		_ = json.NewEncoder(w).Encode(v.data)
+	}))
+	t.Cleanup(srv.Close)
+	return v, NewClient(srv.URL)
+}
+
+func TestRecordServiceUpdateIfUnchanged(t *testing.T) {
+	v, c := newVersionedServer(t, false)
+	ctx := context.Background()
+	base, err := c.Records.GetOne(ctx, "posts", "r1", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rec, err := c.Records.(*RecordService).UpdateIfUnchanged(ctx, "posts", base, map[string]any{"title": "b"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.GetString("title") != "b" {
+		t.Fatalf("unexpected title: %q", rec.GetString("title"))
+	}
+
+	// base is now stale.
+	_, err = c.Records.(*RecordService).UpdateIfUnchanged(ctx, "posts", base, map[string]any{"title": "c"}, nil)
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected a ConflictError, got %v", err)
+	}
+	if conflict.Current == nil || conflict.Current.GetString("title") != "b" || conflict.Field != "updated" {
+		t.Fatalf("unexpected conflict: %+v", conflict)
+	}
+	if v.patches != 1 {
+		t.Fatalf("got %d writes, want 1", v.patches)
+	}
+}
+
+func TestRecordServiceUpdateIfUnchangedMerge(t *testing.T) {
+	v, c := newVersionedServer(t, false)
+	ctx := context.Background()
+	base, _ := c.Records.GetOne(ctx, "posts", "r1", nil)
+	if _, err := c.Records.Update(ctx, "posts", "r1", map[string]any{"count": 5}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	merges := 0
+	rec, err := c.Records.(*RecordService).UpdateIfUnchanged(ctx, "posts", base, map[string]any{"count": base.GetFloat("count") + 1}, &UpdateIfUnchangedOptions{
+		Merge: func(current *Record, body any) (any, error) {
+			merges++
+			return map[string]any{"count": current.GetFloat("count") + 1}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if merges != 1 || rec.GetFloat("count") != 6 {
+		t.Fatalf("got count %v after %d merges", rec.GetFloat("count"), merges)
+	}
+	if v.patches != 2 {
+		t.Fatalf("got %d writes, want 2", v.patches)
+	}
+}
+
+func TestRecordServiceUpdateIfUnchangedRetriesExhausted(t *testing.T) {
+	v, c := newVersionedServer(t, true)
+	ctx := context.Background()
+	base, _ := c.Records.GetOne(ctx, "posts", "r1", nil)
+
+	merges := 0
+	_, err := c.Records.(*RecordService).UpdateIfUnchanged(ctx, "posts", base, map[string]any{"title": "x"}, &UpdateIfUnchangedOptions{
+		MaxRetries: 2,
+		Merge: func(current *Record, body any) (any, error) {
+			merges++
+			return body, nil
+		},
+	})
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	if merges != 2 || v.patches != 0 {
+		t.Fatalf("got %d merges and %d writes", merges, v.patches)
+	}
+
+	stop := errors.New("stop")
+	_, err = c.Records.(*RecordService).UpdateIfUnchanged(ctx, "posts", base, nil, &UpdateIfUnchangedOptions{
+		Merge: func(*Record, any) (any, error) { return nil, stop },
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("expected the merge error, got %v", err)
+	}
+}
+
+func TestTypedRecordServiceUpdateIfUnchanged(t *testing.T) {
+	_, c := newVersionedServer(t, false)
+	ctx := context.Background()
+	svc := NewTypedRecordService[testPost](c, "posts")
+	base, err := svc.GetOne(ctx, "r1", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	opts := &TypedUpdateIfUnchangedOptions[testPost]{VersionField: "title"}
+	post, err := svc.UpdateIfUnchanged(ctx, base, &testPost{Title: "b"}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if post.Title != "b" {
+		t.Fatalf("unexpected title: %q", post.Title)
+	}
+
+	opts.Merge = func(current, body *testPost) (*testPost, error) {
+		return &testPost{Title: current.Title + "+c"}, nil
+	}
+	post, err = svc.UpdateIfUnchanged(ctx, base, &testPost{Title: "c"}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if post.Title != "b+c" {
+		t.Fatalf("unexpected title: %q", post.Title)
+	}
+}
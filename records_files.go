@@ -0,0 +1,149 @@
+package pocketbase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// FileUpload is a file sent with CreateWithFiles or UpdateWithFiles.
+type FileUpload struct {
+	// Field is the file field, e.g. "documents". On updates, files replace
+	// the field's current files unless the field is written with a modifier:
+	// "documents+" appends and "+documents" prepends. To delete files, put
+	// their names under "documents-" in the body, e.g. with Patch.Remove.
+	Field string
+	// Filename is the name the file is uploaded as.
+	Filename string
+	// ContentType defaults to the type registered for Filename's extension,
+	// or application/octet-stream.
+	ContentType string
+	// Reader is the file content.
+	Reader io.Reader
+}
+
+// NewFileUpload returns a FileUpload for field with the default content type.
+func NewFileUpload(field, filename string, r io.Reader) FileUpload {
+	return FileUpload{Field: field, Filename: filename, Reader: r}
+}
+
+// CreateWithFiles creates a record with its files in a single multipart
+// request, so a failed upload doesn't leave a record without its files.
+// body holds the other fields (a map, struct or Mappable) and may be nil.
+func (s *RecordService) CreateWithFiles(ctx context.Context, collection string, body any, files []FileUpload, opts *WriteOptions) (*Record, error) {
+	path := fmt.Sprintf("/api/collections/%s/records", url.PathEscape(collection))
+	var rec Record
+	if err := s.sendMultipart(ctx, http.MethodPost, path, body, files, opts, &rec); err != nil {
+		return nil, fmt.Errorf("pocketbase: create record with files: %w", err)
+	}
+	return &rec, nil
+}
+
+// UpdateWithFiles updates a record and uploads files in a single multipart
+// request. body holds the other fields, including modifiers such as
+// "documents-" to delete files, and may be nil.
+func (s *RecordService) UpdateWithFiles(ctx context.Context, collection, recordID string, body any, files []FileUpload, opts *WriteOptions) (*Record, error) {
+	path := fmt.Sprintf("/api/collections/%s/records/%s", url.PathEscape(collection), url.PathEscape(recordID))
+	var rec Record
+	if err := s.sendMultipart(ctx, http.MethodPatch, path, body, files, opts, &rec); err != nil {
+		return nil, fmt.Errorf("pocketbase: update record with files: %w", err)
+	}
+	return &rec, nil
+}
+
+// sendMultipart sends body as the @jsonPayload field next to files. The form
+// is buffered in memory so the request can be replayed on an auth retry.
+func (s *RecordService) sendMultipart(ctx context.Context, method, path string, body any, files []FileUpload, opts *WriteOptions, result any) error {
+	q := url.Values{}
+	opts.apply(q)
+	if qs := q.Encode(); qs != "" {
+		path += "?" + qs
+	}
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	if body != nil {
+		if mappable, ok := body.(Mappable); ok {
+			body = mappable.ToMap()
+		}
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encode body: %w", err)
+		}
+		if err := writer.WriteField("@jsonPayload", string(payload)); err != nil {
+			return fmt.Errorf("write body: %w", err)
+		}
+	}
+	for _, f := range files {
+		if err := writeFileUpload(writer, f); err != nil {
+			return err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("close multipart writer: %w", err)
+	}
+	return s.Client.do(ctx, method, path, &buf, writer.FormDataContentType(), result)
+}
+
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func writeFileUpload(writer *multipart.Writer, f FileUpload) error {
+	switch {
+	case f.Field == "":
+		return fmt.Errorf("file %q has no field", f.Filename)
+	case f.Filename == "":
+		return fmt.Errorf("file for field %q has no filename", f.Field)
+	case f.Reader == nil:
+		return fmt.Errorf("file %q has no reader", f.Filename)
+	}
+	contentType := f.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(f.Filename))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		quoteEscaper.Replace(f.Field), quoteEscaper.Replace(f.Filename)))
+	h.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(h)
+	if err != nil {
+		return fmt.Errorf("create part for %q: %w", f.Filename, err)
+	}
+	if _, err := io.Copy(part, f.Reader); err != nil {
+		return fmt.Errorf("read %q: %w", f.Filename, err)
+	}
+	return nil
+}
+
+// CreateWithFiles creates a record from body with its files in a single
+// request. See RecordService.CreateWithFiles.
+func (s *TypedRecordService[T]) CreateWithFiles(ctx context.Context, body *T, files []FileUpload, opts *WriteOptions) (*T, error) {
+	rec, err := s.RecordService.CreateWithFiles(ctx, s.Collection, body, files, opts)
+	if err != nil {
+		return nil, err
+	}
+	return convertRecord[T](rec)
+}
+
+// UpdateWithFiles updates a record and uploads files in a single request.
+// body may be a *T, a patch or a map. See RecordService.UpdateWithFiles.
+func (s *TypedRecordService[T]) UpdateWithFiles(ctx context.Context, recordID string, body any, files []FileUpload, opts *WriteOptions) (*T, error) {
+	rec, err := s.RecordService.UpdateWithFiles(ctx, s.Collection, recordID, body, files, opts)
+	if err != nil {
+		return nil, err
+	}
+	return convertRecord[T](rec)
+}
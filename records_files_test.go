@@ -0,0 +1,127 @@
+package pocketbase
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+)
+
+type uploadedFile struct {
+	Field, Filename, ContentType, Content string
+}
+
+// newMultipartServer records the JSON payload and files of multipart requests.
+func newMultipartServer(t *testing.T, method, path string, payload *map[string]any, files *[]uploadedFile) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method || r.URL.Path != path {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.URL.Query().Get("expand"); got != "author" {
+			t.Errorf("unexpected expand: %q", got)
+		}
+		mr, err := r.MultipartReader()
+		if err != nil {
+			t.Fatalf("expected a multipart request: %v", err)
+		}
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("read part: %v", err)
+			}
+			data, _ := io.ReadAll(part)
+			if part.FormName() == "@jsonPayload" {
+				_ = json.Unmarshal(data, payload)
+				continue
+			}
+			*files = append(*files, uploadedFile{part.FormName(), part.FileName(), part.Header.Get("Content-Type"), string(data)})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": "p1", "title": "Hello"})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRecordServiceCreateWithFiles(t *testing.T) {
+	var payload map[string]any
+	var files []uploadedFile
+	srv := newMultipartServer(t, http.MethodPost, "/api/collections/posts/records", &payload, &files)
+	c := NewClient(srv.URL)
+
+	rec, err := c.Records.(*RecordService).CreateWithFiles(context.Background(), "posts",
+		map[string]any{"title": "Hello", "tags": []string{"go"}},
+		[]FileUpload{
+			NewFileUpload("documents", "a.json", strings.NewReader("A")),
+			NewFileUpload("documents", "b.pdf", strings.NewReader("B")),
+			{Field: "cover", Filename: `we"ird.bin`, ContentType: "image/webp", Reader: strings.NewReader("C")},
+		},
+		&WriteOptions{Expand: "author"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.ID != "p1" {
+		t.Fatalf("unexpected record: %s", rec.ID)
+	}
+	if want := map[string]any{"title": "Hello", "tags": []any{"go"}}; !reflect.DeepEqual(payload, want) {
+		t.Fatalf("got payload %v, want %v", payload, want)
+	}
+	want := []uploadedFile{
+		{"documents", "a.json", "application/json", "A"},
+		{"documents", "b.pdf", "application/pdf", "B"},
+		{"cover", `we"ird.bin`, "image/webp", "C"},
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("got files %v, want %v", files, want)
+	}
+}
+
+func TestTypedRecordServiceUpdateWithFiles(t *testing.T) {
+	var payload map[string]any
+	var files []uploadedFile
+	srv := newMultipartServer(t, http.MethodPatch, "/api/collections/posts/records/p1", &payload, &files)
+	svc := NewTypedRecordService[testPost](NewClient(srv.URL), "posts")
+
+	patch := NewPatch().Set("title", "Hello").Remove("documents", "old.txt")
+	post, err := svc.UpdateWithFiles(context.Background(), "p1", patch,
+		[]FileUpload{NewFileUpload("documents+", "new.txt", strings.NewReader("N"))},
+		&WriteOptions{Expand: "author"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if post.Title != "Hello" {
+		t.Fatalf("unexpected record: %+v", post)
+	}
+	if want := map[string]any{"title": "Hello", "documents-": []any{"old.txt"}}; !reflect.DeepEqual(payload, want) {
+		t.Fatalf("got payload %v, want %v", payload, want)
+	}
+	if len(files) != 1 || files[0].Field != "documents+" || files[0].Content != "N" {
+		t.Fatalf("unexpected files: %v", files)
+	}
+}
+
+func TestRecordServiceCreateWithFilesInvalid(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL)
+
+	for _, f := range []FileUpload{
+		{Filename: "a.txt", Reader: strings.NewReader("")},
+		{Field: "documents", Reader: strings.NewReader("")},
+		{Field: "documents", Filename: "a.txt"},
+	} {
+		if _, err := c.Records.(*RecordService).CreateWithFiles(context.Background(), "posts", nil, []FileUpload{f}, nil); err == nil {
+			t.Errorf("expected an error for %+v", f)
+		}
+	}
+}
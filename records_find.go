@@ -0,0 +1,146 @@
+package pocketbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// newRecordNotFoundError is returned by GetFirstListItem when nothing matches.
+// It matches ErrRecordNotFound with errors.Is, like a 404 from GetOne.
+func newRecordNotFoundError() *Error {
+	return &Error{Status: http.StatusNotFound, Code: ErrRecordNotFound.Code, Message: "Record not found."}
+}
+
+// isUniqueViolation reports whether err is a validation error caused by a
+// unique constraint, as returned when a concurrent request created the same
+// record first.
+func isUniqueViolation(err error) bool {
+	var pbErr *Error
+	if !errors.As(err, &pbErr) || pbErr.Status != http.StatusBadRequest {
+		return false
+	}
+	for _, fe := range pbErr.Data {
+		if fe.Code == "validation_not_unique" {
+			return true
+		}
+	}
+	return false
+}
+
+// GetFirstListItem returns the first record of collection matching filter,
+// ordered by opts.Sort. filter replaces opts.Filter, and opts may be nil.
+// If nothing matches, the error matches ErrRecordNotFound with errors.Is.
+func (s *RecordService) GetFirstListItem(ctx context.Context, collection, filter string, opts *ListOptions) (*Record, error) {
+	var o ListOptions
+	if opts != nil {
+		o = *opts
+	}
+	o.Filter = filter
+	o.Page = 1
+	o.PerPage = 1
+	o.SkipTotal = true
+	res, err := s.GetList(ctx, collection, &o)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Items) == 0 {
+		return nil, newRecordNotFoundError()
+	}
+	return res.Items[0], nil
+}
+
+// FindOrCreate returns the first record of collection matching filter, or
+// creates it from body if there is none. created reports whether the record
+// was created. If a concurrent request creates a matching record first and
+// the create fails on a unique field, the lookup is repeated once.
+// filter is required, since an empty filter would match any record.
+func (s *RecordService) FindOrCreate(ctx context.Context, collection, filter string, body any) (rec *Record, created bool, err error) {
+	if strings.TrimSpace(filter) == "" {
+		return nil, false, fmt.Errorf("pocketbase: find or create: filter is required")
+	}
+	rec, err = s.GetFirstListItem(ctx, collection, filter, nil)
+	if err == nil || !errors.Is(err, ErrRecordNotFound) {
+		return rec, false, err
+	}
+	rec, err = s.Create(ctx, collection, body)
+	if err == nil {
+		return rec, true, nil
+	}
+	if !isUniqueViolation(err) {
+		return nil, false, err
+	}
+	if rec, ferr := s.GetFirstListItem(ctx, collection, filter, nil); ferr == nil {
+		return rec, false, nil
+	}
+	return nil, false, err
+}
+
+// Upsert updates the record of collection whose field equals value with body,
+// or creates a record from body if there is none. field should be unique in
+// the collection and body should set it to value. created reports whether the
+// record was created. If a concurrent request creates the record first, the
+// update is retried once.
+func (s *RecordService) Upsert(ctx context.Context, collection, field string, value, body any) (rec *Record, created bool, err error) {
+	filter, err := F(field).Eq(value).Build()
+	if err != nil {
+		return nil, false, err
+	}
+	update := func() (*Record, error) {
+		existing, err := s.GetFirstListItem(ctx, collection, filter, &ListOptions{Fields: "id"})
+		if err != nil {
+			return nil, err
+		}
+		return s.Update(ctx, collection, existing.ID, body)
+	}
+
+	rec, err = update()
+	if err == nil || !errors.Is(err, ErrRecordNotFound) {
+		return rec, false, err
+	}
+	rec, err = s.Create(ctx, collection, body)
+	if err == nil {
+		return rec, true, nil
+	}
+	if !isUniqueViolation(err) {
+		return nil, false, err
+	}
+	if rec, uerr := update(); uerr == nil {
+		return rec, false, nil
+	}
+	return nil, false, err
+}
+
+// GetFirstListItem returns the first record matching filter, converted to T.
+// See RecordService.GetFirstListItem.
+func (s *TypedRecordService[T]) GetFirstListItem(ctx context.Context, filter string, opts *ListOptions) (*T, error) {
+	rec, err := s.RecordService.GetFirstListItem(ctx, s.Collection, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	return convertRecord[T](rec)
+}
+
+// FindOrCreate returns the first record matching filter, or creates it from
+// body. See RecordService.FindOrCreate.
+func (s *TypedRecordService[T]) FindOrCreate(ctx context.Context, filter string, body *T) (*T, bool, error) {
+	rec, created, err := s.RecordService.FindOrCreate(ctx, s.Collection, filter, body)
+	if err != nil {
+		return nil, false, err
+	}
+	item, err := convertRecord[T](rec)
+	return item, created, err
+}
+
+// Upsert updates the record whose field equals value, or creates one from
+// body. See RecordService.Upsert.
+func (s *TypedRecordService[T]) Upsert(ctx context.Context, field string, value any, body *T) (*T, bool, error) {
+	rec, created, err := s.RecordService.Upsert(ctx, s.Collection, field, value, body)
+	if err != nil {
+		return nil, false, err
+	}
+	item, err := convertRecord[T](rec)
+	return item, created, err
+}
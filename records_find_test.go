@@ -0,0 +1,214 @@
+package pocketbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/goccy/go-json"
+)
+
+// fakeUsers is an in-memory "users" collection with a unique email field.
+// Lists understand the filter "email = '<value>'". If raceCreate is set, the
+// first create fails as if another client had created the record first.
+type fakeUsers struct {
+	mu         sync.Mutex
+	records    map[string]map[string]any // by email
+	creates    int
+	updates    int
+	raceCreate bool
+}
+
+func (f *fakeUsers) handler(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/collections/users/records":
+			q := r.URL.Query()
+			if q.Get("perPage") != "1" || q.Get("skipTotal") != "1" {
+				t.Errorf("unexpected query: %s", r.URL.RawQuery)
+			}
+			res := ListResult{Items: []*Record{}}
+			email := strings.TrimSuffix(strings.TrimPrefix(q.Get("filter"), "email = '"), "'")
+			if data, ok := f.records[email]; ok {
+				res.Items = append(res.Items, recordFromMap(data))
+			}
+			_ = json.NewEncoder(w).Encode(res)
+		case r.Method == http.MethodPost:
+			f.creates++
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			email, _ := body["email"].(string)
+			if f.raceCreate {
+				f.raceCreate = false
+				f.records[email] = map[string]any{"id": "raced", "email": email, "name": "other"}
+			}
+			if _, ok := f.records[email]; ok {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = io.WriteString(w, `{"status":400,"message":"Failed to create record.","data":{"email":{"code":"validation_not_unique","message":"Value must be unique."}}}`)
+				return
+			}
+			body["id"] = fmt.Sprintf("id%d", len(f.records))
+			f.records[email] = body
+			_ = json.NewEncoder(w).Encode(recordFromMap(body))
+		case r.Method == http.MethodPatch:
+			f.updates++
+			id := strings.TrimPrefix(r.URL.Path, "/api/collections/users/records/")
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			for _, data := range f.records {
+				if data["id"] == id {
+					for k, v := range body {
+						data[k] = v
+					}
+					_ = json.NewEncoder(w).Encode(recordFromMap(data))
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+		}
+	})
+}
+
+func recordFromMap(data map[string]any) *Record {
+	rec := &Record{}
+	for k, v := range data {
+		if k == "id" {
+			rec.ID = v.(string)
+			continue
+		}
+		rec.Set(k, v)
+	}
+	return rec
+}
+
+func newFakeUsers(t *testing.T, raceCreate bool) (*fakeUsers, *Client) {
+	f := &fakeUsers{records: map[string]map[string]any{}, raceCreate: raceCreate}
+	srv := httptest.NewServer(f.handler(t))
+	t.Cleanup(srv.Close)
+	return f, NewClient(srv.URL)
+}
+
+func TestRecordServiceGetFirstListItem(t *testing.T) {
+	f, c := newFakeUsers(t, false)
+	f.records["a@example.com"] = map[string]any{"id": "a", "email": "a@example.com"}
+
+	rec, err := c.Records.(*RecordService).GetFirstListItem(context.Background(), "users", "email = 'a@example.com'", &ListOptions{PerPage: 50})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.ID != "a" {
+		t.Fatalf("unexpected record: %s", rec.ID)
+	}
+
+	_, err = c.Records.(*RecordService).GetFirstListItem(context.Background(), "users", "email = 'b@example.com'", nil)
+	if !errors.Is(err, ErrRecordNotFound) || !IsNotFoundError(err) {
+		t.Fatalf("expected ErrRecordNotFound, got %v", err)
+	}
+}
+
+func TestRecordServiceFindOrCreate(t *testing.T) {
+	f, c := newFakeUsers(t, false)
+	body := map[string]any{"email": "a@example.com"}
+
+	rec, created, err := c.Records.(*RecordService).FindOrCreate(context.Background(), "users", "email = 'a@example.com'", body)
+	if err != nil || !created {
+		t.Fatalf("expected a created record, got %v, %v", created, err)
+	}
+	again, created, err := c.Records.(*RecordService).FindOrCreate(context.Background(), "users", "email = 'a@example.com'", body)
+	if err != nil || created || again.ID != rec.ID {
+		t.Fatalf("expected the existing record %s, got %v, %v, %v", rec.ID, again, created, err)
+	}
+	if f.creates != 1 {
+		t.Fatalf("got %d creates, want 1", f.creates)
+	}
+}
+
+func TestRecordServiceFindOrCreateEmptyFilter(t *testing.T) {
+	f, c := newFakeUsers(t, false)
+
+	for _, filter := range []string{"", "  "} {
+		if _, _, err := c.Records.(*RecordService).FindOrCreate(context.Background(), "users", filter, map[string]any{"email": "a@example.com"}); err == nil {
+			t.Errorf("expected an error for filter %q", filter)
+		}
+	}
+	if f.creates != 0 {
+		t.Fatalf("got %d creates, want 0", f.creates)
+	}
+}
+
+func TestRecordServiceFindOrCreateRace(t *testing.T) {
+	_, c := newFakeUsers(t, true)
+
+	rec, created, err := c.Records.(*RecordService).FindOrCreate(context.Background(), "users", "email = 'a@example.com'", map[string]any{"email": "a@example.com"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created || rec.ID != "raced" {
+		t.Fatalf("expected the concurrently created record, got %s (created=%v)", rec.ID, created)
+	}
+}
+
+func TestRecordServiceUpsert(t *testing.T) {
+	f, c := newFakeUsers(t, false)
+
+	rec, created, err := c.Records.(*RecordService).Upsert(context.Background(), "users", "email", "a@example.com", map[string]any{"email": "a@example.com", "name": "A"})
+	if err != nil || !created {
+		t.Fatalf("expected a created record, got %v, %v", created, err)
+	}
+	updated, created, err := c.Records.(*RecordService).Upsert(context.Background(), "users", "email", "a@example.com", map[string]any{"name": "B"})
+	if err != nil || created {
+		t.Fatalf("expected an update, got %v, %v", created, err)
+	}
+	if updated.ID != rec.ID || updated.GetString("name") != "B" {
+		t.Fatalf("unexpected record: %s %q", updated.ID, updated.GetString("name"))
+	}
+	if f.creates != 1 || f.updates != 1 {
+		t.Fatalf("got %d creates and %d updates", f.creates, f.updates)
+	}
+
+	if _, _, err := c.Records.(*RecordService).Upsert(context.Background(), "users", "bad field", "x", nil); err == nil {
+		t.Fatal("expected an error for an invalid field")
+	}
+}
+
+func TestRecordServiceUpsertRace(t *testing.T) {
+	f, c := newFakeUsers(t, true)
+
+	rec, created, err := c.Records.(*RecordService).Upsert(context.Background(), "users", "email", "a@example.com", map[string]any{"email": "a@example.com", "name": "A"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created || rec.ID != "raced" || rec.GetString("name") != "A" {
+		t.Fatalf("expected the concurrently created record to be updated, got %s %q (created=%v)", rec.ID, rec.GetString("name"), created)
+	}
+	if f.updates != 1 {
+		t.Fatalf("got %d updates, want 1", f.updates)
+	}
+}
+
+func TestTypedRecordServiceGetFirstListItem(t *testing.T) {
+	f, c := newFakeUsers(t, false)
+	f.records["a@example.com"] = map[string]any{"id": "a", "email": "a@example.com", "title": "hello"}
+	svc := NewTypedRecordService[testPost](c, "users")
+
+	post, err := svc.GetFirstListItem(context.Background(), "email = 'a@example.com'", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if post.ID != "a" || post.Title != "hello" {
+		t.Fatalf("unexpected record: %+v", post)
+	}
+	if _, err := svc.GetFirstListItem(context.Background(), "email = 'x'", nil); !errors.Is(err, ErrRecordNotFound) {
+		t.Fatalf("expected ErrRecordNotFound, got %v", err)
+	}
+}
@@ -558,3 +558,17 @@ type TestUser struct {
 	Name   string `json:"name"`
 	Avatar string `json:"avatar"`
 }
+
+// testPost is a minimal RecordModel implementation, as generated by pbc-gen.
+type testPost struct {
+	ID             string `json:"id"`
+	CollectionID   string `json:"collectionId"`
+	CollectionName string `json:"collectionName"`
+	Title          string `json:"title"`
+}
+
+func (p *testPost) GetID() string              { return p.ID }
+func (p *testPost) GetCollectionName() string  { return p.CollectionName }
+func (p *testPost) SetID(id string)            { p.ID = id }
+func (p *testPost) SetCollectionID(id string)  { p.CollectionID = id }
+func (p *testPost) SetCollectionName(n string) { p.CollectionName = n }
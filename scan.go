@@ -0,0 +1,296 @@
+package pocketbase
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"iter"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-json"
+)
+
+// ErrInvalidCursor is returned when a scan cursor is malformed or was created
+// for a different sort or filter.
+var ErrInvalidCursor = errors.New("pocketbase: invalid scan cursor")
+
+// scanKeyPattern matches the sort keys a scan can seek on: top-level fields
+// whose values are present in the returned records.
+var scanKeyPattern = regexp.MustCompile(`^[+-]?[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ScanOptions configures a keyset scan.
+type ScanOptions struct {
+	// Sort is the scan order, e.g. "created" or "-updated". "id" is always
+	// appended as a tie-breaker, so the order is total. Only top-level fields
+	// are allowed; the default is "id".
+	Sort string
+	// Filter restricts the scanned records.
+	Filter string
+	// Expand and Fields are passed on to every page request. Sort fields are
+	// added to Fields if it is set, since they are needed to advance.
+	Expand string
+	Fields string
+	// PerPage is the page size (default 100).
+	PerPage int
+	// Cursor resumes a previous scan; see RecordScan.Cursor.
+	Cursor string
+	// QueryParams are additional query parameters.
+	QueryParams map[string]string
+}
+
+// RecordScan walks a collection in a stable order using keyset (cursor)
+// pagination: instead of page numbers, each page is requested with a filter
+// on the sort values of the last record seen. Unlike offset pagination it
+// doesn't slow down on deep pages and doesn't skip or repeat records when
+// other records are created or deleted during the scan.
+//
+// A scan can be resumed from Cursor after a failure:
+//
+//	scan := client.Records.(*pocketbase.RecordService).Scan("events", &pocketbase.ScanOptions{Sort: "created", Cursor: saved})
+//	for rec, err := range scan.All(ctx) {
+//		if err != nil {
+//			saved = scan.Cursor() // resume from here later
+//			return err
+//		}
+//		export(rec)
+//	}
+//
+// The zero value is not usable; All reports an error for it.
+type RecordScan struct {
+	records    RecordServiceAPI
+	collection string
+	opts       ScanOptions
+	keys       []scanKey
+	err        error
+
+	mu     sync.Mutex
+	values []any // sort values of the last record yielded
+}
+
+type scanKey struct {
+	field string
+	desc  bool
+}
+
+// scanCursor is the decoded form of a cursor token.
+type scanCursor struct {
+	Sort   string `json:"s"`
+	Filter string `json:"f,omitempty"`
+	Values []any  `json:"v"`
+}
+
+// Scan prepares a keyset scan of collection. Nothing is requested until the
+// iterator returned by All is used. Invalid options are reported by the
+// iterator.
+func (s *RecordService) Scan(collection string, opts *ScanOptions) *RecordScan {
+	return NewRecordScan(s, collection, opts)
+}
+
+// NewRecordScan prepares a keyset scan of collection that lists pages with
+// records, which may be any RecordServiceAPI implementation.
+// See RecordService.Scan.
+func NewRecordScan(records RecordServiceAPI, collection string, opts *ScanOptions) *RecordScan {
+	scan := &RecordScan{records: records, collection: collection}
+	if opts != nil {
+		scan.opts = *opts
+	}
+	if scan.opts.PerPage <= 0 {
+		scan.opts.PerPage = iteratePerPage
+	}
+	scan.keys, scan.err = parseScanSort(scan.opts.Sort)
+	if scan.err == nil && scan.opts.Cursor != "" {
+		scan.values, scan.err = scan.decodeCursor(scan.opts.Cursor)
+	}
+	return scan
+}
+
+func parseScanSort(sort string) ([]scanKey, error) {
+	var keys []scanKey
+	for key := range strings.SplitSeq(sort, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		if !scanKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("pocketbase: scan can't sort by %q", key)
+		}
+		k := scanKey{field: strings.TrimLeft(key, "+-"), desc: strings.HasPrefix(key, "-")}
+		if slices.ContainsFunc(keys, func(e scanKey) bool { return e.field == k.field }) {
+			continue
+		}
+		keys = append(keys, k)
+		if k.field == "id" {
+			break // ids are unique, later keys never apply
+		}
+	}
+	if len(keys) == 0 || keys[len(keys)-1].field != "id" {
+		keys = append(keys, scanKey{field: "id"})
+	}
+	return keys, nil
+}
+
+func (s *RecordScan) sort() string {
+	parts := make([]string, len(s.keys))
+	for i, k := range s.keys {
+		parts[i] = k.field
+		if k.desc {
+			parts[i] = "-" + k.field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// All returns an iterator over the remaining records of the scan. Iteration
+// stops at the first error, which is yielded with a nil record.
+func (s *RecordScan) All(ctx context.Context) iter.Seq2[*Record, error] {
+	return func(yield func(*Record, error) bool) {
+		if s.err != nil {
+			yield(nil, s.err)
+			return
+		}
+		if s.records == nil {
+			yield(nil, fmt.Errorf("pocketbase: scan has no record service; use RecordService.Scan or NewRecordScan"))
+			return
+		}
+		opts := &ListOptions{
+			Page:        1,
+			PerPage:     s.opts.PerPage,
+			Sort:        s.sort(),
+			Expand:      s.opts.Expand,
+			Fields:      s.fields(),
+			SkipTotal:   true,
+			QueryParams: s.opts.QueryParams,
+		}
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+			s.mu.Lock()
+			seek := s.seekFilter(s.values)
+			s.mu.Unlock()
+			filter := And(FilterExpr{expr: s.opts.Filter}, seek)
+			if err := filter.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+			opts.Filter = filter.String()
+
+			res, err := s.records.GetList(ctx, s.collection, opts)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, rec := range res.Items {
+				s.mu.Lock()
+				s.values = s.recordValues(rec)
+				s.mu.Unlock()
+				if !yield(rec, nil) {
+					return
+				}
+			}
+			perPage := res.PerPage
+			if perPage <= 0 {
+				perPage = opts.PerPage
+			}
+			if len(res.Items) == 0 || len(res.Items) < perPage {
+				return
+			}
+		}
+	}
+}
+
+// fields adds the sort keys to a restricted field list.
+func (s *RecordScan) fields() string {
+	if s.opts.Fields == "" {
+		return ""
+	}
+	fields := s.opts.Fields
+	listed := strings.Split(fields, ",")
+	for i := range listed {
+		listed[i] = strings.TrimSpace(listed[i])
+	}
+	if slices.Contains(listed, "*") {
+		return fields
+	}
+	for _, k := range s.keys {
+		if !slices.Contains(listed, k.field) {
+			fields += "," + k.field
+		}
+	}
+	return fields
+}
+
+// seekFilter matches the records after values in scan order:
+// k1 > v1 || (k1 = v1 && k2 > v2) || ...
+func (s *RecordScan) seekFilter(values []any) FilterExpr {
+	if values == nil {
+		return FilterExpr{}
+	}
+	var alts []FilterExpr
+	for i, k := range s.keys {
+		var conds []FilterExpr
+		for j := range i {
+			conds = append(conds, F(s.keys[j].field).Eq(values[j]))
+		}
+		if k.desc {
+			conds = append(conds, F(k.field).Lt(values[i]))
+		} else {
+			conds = append(conds, F(k.field).Gt(values[i]))
+		}
+		alts = append(alts, And(conds...))
+	}
+	return Or(alts...)
+}
+
+func (s *RecordScan) recordValues(rec *Record) []any {
+	values := make([]any, len(s.keys))
+	for i, k := range s.keys {
+		if k.field == "id" {
+			values[i] = rec.ID
+		} else {
+			values[i] = rec.Get(k.field)
+		}
+	}
+	return values
+}
+
+// Cursor returns an opaque token that resumes the scan after the last record
+// yielded, or the starting cursor if no record has been yielded yet. It is
+// empty for a scan that started from the beginning and hasn't yielded any
+// record.
+func (s *RecordScan) Cursor() string {
+	s.mu.Lock()
+	values := s.values
+	s.mu.Unlock()
+	if values == nil {
+		return s.opts.Cursor
+	}
+	data, err := json.Marshal(scanCursor{Sort: s.sort(), Filter: s.opts.Filter, Values: values})
+	if err != nil {
+		return s.opts.Cursor
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (s *RecordScan) decodeCursor(token string) ([]any, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	var c scanCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if c.Sort != s.sort() || c.Filter != s.opts.Filter {
+		return nil, fmt.Errorf("%w: created for a different sort or filter", ErrInvalidCursor)
+	}
+	if len(c.Values) != len(s.keys) {
+		return nil, fmt.Errorf("%w: expected %d values, got %d", ErrInvalidCursor, len(s.keys), len(c.Values))
+	}
+	return c.Values, nil
+}
@@ -0,0 +1,167 @@
+package pocketbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/goccy/go-json"
+)
+
+// newScanServer serves records r0..r{total-1} ordered by id and understands
+// the filter "id > 'rN'" produced by a scan with the default sort.
+func newScanServer(t *testing.T, total int) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var filters []string
+	seek := regexp.MustCompile(`^id > 'r(\d+)'$`)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("page") != "1" || q.Get("skipTotal") != "1" || q.Get("sort") != "id" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		filter := q.Get("filter")
+		mu.Lock()
+		filters = append(filters, filter)
+		mu.Unlock()
+
+		start := 0
+		if m := seek.FindStringSubmatch(filter); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			start = n + 1
+		} else if filter != "" {
+			t.Errorf("unexpected filter: %q", filter)
+		}
+		perPage, _ := strconv.Atoi(q.Get("perPage"))
+		res := ListResult{Page: 1, PerPage: perPage, Items: []*Record{}}
+		for i := start; i < start+perPage && i < total; i++ {
+			res.Items = append(res.Items, &Record{ID: fmt.Sprintf("r%d", i)})
+		}
+		_ = json.NewEncoder(w).Encode(res)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), filters...)
+	}
+}
+
+func TestRecordServiceScan(t *testing.T) {
+	srv, filters := newScanServer(t, 5)
+	c := NewClient(srv.URL)
+
+	var ids []string
+	for rec, err := range c.Records.(*RecordService).Scan("posts", &ScanOptions{PerPage: 2}).All(context.Background()) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, rec.ID)
+	}
+	if fmt.Sprint(ids) != "[r0 r1 r2 r3 r4]" {
+		t.Fatalf("unexpected records: %v", ids)
+	}
+	if got := fmt.Sprintf("%q", filters()); got != `["" "id > 'r1'" "id > 'r3'"]` {
+		t.Fatalf("unexpected filters: %s", got)
+	}
+}
+
+func TestNewRecordScan(t *testing.T) {
+	srv, _ := newScanServer(t, 3)
+	// A wrapper stands in for a custom RecordServiceAPI implementation.
+	records := struct{ RecordServiceAPI }{NewClient(srv.URL).Records}
+
+	var ids []string
+	for rec, err := range NewRecordScan(records, "posts", &ScanOptions{PerPage: 2}).All(context.Background()) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, rec.ID)
+	}
+	if fmt.Sprint(ids) != "[r0 r1 r2]" {
+		t.Fatalf("unexpected records: %v", ids)
+	}
+
+	var zeroErr error
+	for _, err := range (&RecordScan{}).All(context.Background()) {
+		zeroErr = err
+	}
+	if zeroErr == nil {
+		t.Fatal("expected an error from the zero value")
+	}
+}
+
+func TestRecordServiceScanResume(t *testing.T) {
+	srv, _ := newScanServer(t, 5)
+	c := NewClient(srv.URL)
+
+	scan := c.Records.(*RecordService).Scan("posts", &ScanOptions{PerPage: 2})
+	if scan.Cursor() != "" {
+		t.Fatalf("expected an empty cursor before the scan")
+	}
+	n := 0
+	for _, err := range scan.All(context.Background()) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if n++; n == 3 {
+			break
+		}
+	}
+	cursor := scan.Cursor()
+	if cursor == "" {
+		t.Fatal("expected a cursor")
+	}
+
+	var ids []string
+	for rec, err := range c.Records.(*RecordService).Scan("posts", &ScanOptions{PerPage: 2, Cursor: cursor}).All(context.Background()) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, rec.ID)
+	}
+	if fmt.Sprint(ids) != "[r3 r4]" {
+		t.Fatalf("unexpected records after resume: %v", ids)
+	}
+
+	for _, err := range c.Records.(*RecordService).Scan("posts", &ScanOptions{Sort: "-created", Cursor: cursor}).All(context.Background()) {
+		if !errors.Is(err, ErrInvalidCursor) {
+			t.Fatalf("expected ErrInvalidCursor for a different sort, got %v", err)
+		}
+	}
+	for _, err := range c.Records.(*RecordService).Scan("posts", &ScanOptions{Cursor: "not a cursor"}).All(context.Background()) {
+		if !errors.Is(err, ErrInvalidCursor) {
+			t.Fatalf("expected ErrInvalidCursor, got %v", err)
+		}
+	}
+}
+
+func TestRecordScanSeekFilter(t *testing.T) {
+	scan := (&RecordService{}).Scan("posts", &ScanOptions{Sort: "-created,title", Filter: "status = 'done'", Fields: "title"})
+	if err := scan.err; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := scan.sort(); got != "-created,title,id" {
+		t.Fatalf("unexpected sort: %s", got)
+	}
+	if got := scan.fields(); got != "title,created,id" {
+		t.Fatalf("unexpected fields: %s", got)
+	}
+	got := scan.seekFilter([]any{"2024-01-01 00:00:00.000Z", "a", "x"}).String()
+	want := `(created < '2024-01-01 00:00:00.000Z') || ((created = '2024-01-01 00:00:00.000Z') && (title > 'a')) || ((created = '2024-01-01 00:00:00.000Z') && (title = 'a') && (id > 'x'))`
+	if got != want {
+		t.Fatalf("unexpected seek filter:\n got %s\nwant %s", got, want)
+	}
+
+	for _, sort := range []string{"@random", "author.name", "title:lower"} {
+		if scan := (&RecordService{}).Scan("posts", &ScanOptions{Sort: sort}); scan.err == nil {
+			t.Errorf("expected an error for sort %q", sort)
+		}
+	}
+}